@@ -0,0 +1,253 @@
+package assets
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/demyanovs/urlcrawler/parser"
+)
+
+// Problems of the assets.
+const (
+	ProblemBroken    = "broken"
+	ProblemOversized = "oversized"
+)
+
+// Result represents a result of the asset check.
+// Size is -1 if the server didn't return the content length.
+type Result struct {
+	StatusCode  int
+	Size        int64
+	ContentType string
+	Error       string
+}
+
+// Issue represents a broken or oversized asset on the page.
+type Issue struct {
+	Page       string `json:"page"`
+	URL        string `json:"url"`
+	Type       string `json:"type"`
+	Problem    string `json:"problem"`
+	StatusCode int    `json:"status code"`
+	Size       int64  `json:"size"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Checker represents a checker of the assets which sends HEAD requests
+// and falls back to GET if HEAD isn't allowed.
+// Delay is the delay between the requests. Allowed reports whether the URL can be requested,
+// e.g. by robots.txt, the disallowed URLs aren't checked. All the URLs are checked if it's nil.
+type Checker struct {
+	Client      *http.Client
+	Concurrency int
+	Timeout     time.Duration
+	Delay       time.Duration
+	UserAgent   string
+	Allowed     func(URL string) bool
+}
+
+// NewChecker creates a new Checker.
+func NewChecker() *Checker {
+	return &Checker{
+		Client:      &http.Client{},
+		Concurrency: 10,
+		Timeout:     5 * time.Second,
+	}
+}
+
+// URLs returns the unique URLs of the assets of the pages.
+func URLs(pages parser.PagesData) []string {
+	var URLs []string
+	seen := make(map[string]bool)
+	for _, p := range pages {
+		for _, a := range p.Assets {
+			if !seen[a.URL] {
+				seen[a.URL] = true
+				URLs = append(URLs, a.URL)
+			}
+		}
+	}
+
+	return URLs
+}
+
+// Check checks the URLs and returns the results by URL.
+// It stops when the context is done and returns the results of the completed checks.
+func (c *Checker) Check(ctx context.Context, URLs []string) map[string]Result {
+	results := make(map[string]Result, len(URLs))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	started := false
+
+	for _, URL := range URLs {
+		if c.Allowed != nil && !c.Allowed(URL) {
+			continue
+		}
+
+		if started && c.Delay > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(c.Delay):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		started = true
+
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(URL string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result := c.check(ctx, URL)
+
+			// The asset isn't broken if the check is canceled
+			if ctx.Err() != nil {
+				return
+			}
+
+			mu.Lock()
+			results[URL] = result
+			mu.Unlock()
+		}(URL)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+func (c *Checker) check(ctx context.Context, URL string) Result {
+	resp, err := c.request(ctx, http.MethodHead, URL)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp, err = c.request(ctx, http.MethodGet, URL)
+	}
+
+	if err != nil {
+		return Result{Size: -1, Error: err.Error()}
+	}
+
+	return Result{
+		StatusCode:  resp.StatusCode,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+}
+
+func (c *Checker) request(ctx context.Context, method string, URL string) (*http.Response, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the headers are needed
+	resp.Body.Close()
+
+	return resp, nil
+}
+
+// Issues returns the broken assets and the assets larger than maxSize bytes for every page.
+// The size isn't checked if maxSize is 0.
+func Issues(pages parser.PagesData, results map[string]Result, maxSize int64) []Issue {
+	var issues []Issue
+	for _, p := range pages {
+		for _, a := range p.Assets {
+			result, ok := results[a.URL]
+			if !ok {
+				continue
+			}
+
+			issue := Issue{
+				Page:       p.URL,
+				URL:        a.URL,
+				Type:       a.Type,
+				StatusCode: result.StatusCode,
+				Size:       result.Size,
+				Error:      result.Error,
+			}
+
+			if result.Error != "" || result.StatusCode >= http.StatusBadRequest {
+				issue.Problem = ProblemBroken
+			} else if maxSize > 0 && result.Size > maxSize {
+				issue.Problem = ProblemOversized
+			} else {
+				continue
+			}
+
+			issues = append(issues, issue)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Page != issues[j].Page {
+			return issues[i].Page < issues[j].Page
+		}
+
+		return issues[i].URL < issues[j].URL
+	})
+
+	return issues
+}
+
+// WriteCSV writes the issues as CSV.
+func WriteCSV(w io.Writer, issues []Issue) error {
+	cw := csv.NewWriter(w)
+
+	data := [][]string{{"Page", "URL", "Type", "Problem", "StatusCode", "Size", "Error"}}
+	for _, issue := range issues {
+		data = append(data, []string{
+			issue.Page,
+			issue.URL,
+			issue.Type,
+			issue.Problem,
+			strconv.Itoa(issue.StatusCode),
+			strconv.FormatInt(issue.Size, 10),
+			issue.Error,
+		})
+	}
+
+	return cw.WriteAll(data)
+}
+
+// WriteJSON writes the issues as JSON.
+func WriteJSON(w io.Writer, issues []Issue) error {
+	if issues == nil {
+		issues = []Issue{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(issues)
+}
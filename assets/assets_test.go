@@ -0,0 +1,141 @@
+package assets
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/logo.png":
+			w.Header().Set("Content-Length", "100")
+		case "/hero.jpg":
+			w.Header().Set("Content-Length", "2048")
+		case "/app.js":
+			// HEAD isn't allowed
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			_, _ = w.Write([]byte("var a;"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	pages := parser.PagesData{
+		{
+			URL: server.URL + "/",
+			Assets: []parser.Asset{
+				{URL: server.URL + "/logo.png", Type: parser.AssetImage},
+				{URL: server.URL + "/hero.jpg", Type: parser.AssetImage},
+				{URL: server.URL + "/app.js", Type: parser.AssetScript},
+				{URL: server.URL + "/missing.css", Type: parser.AssetStylesheet},
+			},
+		},
+		{
+			URL:    server.URL + "/about",
+			Assets: []parser.Asset{{URL: server.URL + "/logo.png", Type: parser.AssetImage}},
+		},
+	}
+
+	URLs := URLs(pages)
+	require.Len(t, URLs, 4)
+
+	results := NewChecker().Check(context.Background(), URLs)
+	require.Equal(t, Result{StatusCode: 200, Size: 6, ContentType: "text/plain; charset=utf-8"}, results[server.URL+"/app.js"])
+
+	issues := Issues(pages, results, 1024)
+	require.Equal(t, []Issue{
+		{
+			Page:       server.URL + "/",
+			URL:        server.URL + "/hero.jpg",
+			Type:       parser.AssetImage,
+			Problem:    ProblemOversized,
+			StatusCode: 200,
+			Size:       2048,
+		},
+		{
+			Page:       server.URL + "/",
+			URL:        server.URL + "/missing.css",
+			Type:       parser.AssetStylesheet,
+			Problem:    ProblemBroken,
+			StatusCode: 404,
+			Size:       results[server.URL+"/missing.css"].Size,
+		},
+	}, issues)
+
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, issues)
+	require.NoError(t, err)
+	require.Equal(t, 3, strings.Count(buf.String(), "\n"))
+	require.True(t, strings.HasPrefix(buf.String(), "Page,URL,Type,Problem,StatusCode,Size,Error\n"))
+}
+
+func TestCheck_ConnectionError(t *testing.T) {
+	results := NewChecker().Check(context.Background(), []string{"http://127.0.0.1:1/logo.png"})
+
+	issues := Issues(parser.PagesData{
+		{URL: "https://example.com/", Assets: []parser.Asset{{URL: "http://127.0.0.1:1/logo.png", Type: parser.AssetImage}}},
+	}, results, 0)
+	require.Len(t, issues, 1)
+	require.Equal(t, ProblemBroken, issues[0].Problem)
+	require.NotEmpty(t, issues[0].Error)
+}
+
+func TestCheck_DelayAllowedSuccess(t *testing.T) {
+	var mu sync.Mutex
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requested = append(requested, r.URL.Path)
+	}))
+	defer server.Close()
+
+	checker := NewChecker()
+	checker.Delay = 50 * time.Millisecond
+	checker.Allowed = func(URL string) bool {
+		return !strings.HasPrefix(URL, server.URL+"/private/")
+	}
+
+	startedAt := time.Now()
+	results := checker.Check(context.Background(), []string{
+		server.URL + "/a.png",
+		server.URL + "/private/b.png",
+		server.URL + "/c.png",
+	})
+	require.GreaterOrEqual(t, time.Since(startedAt), 50*time.Millisecond)
+
+	// The disallowed asset isn't requested and has no result
+	require.Len(t, results, 2)
+	require.NotContains(t, results, server.URL+"/private/b.png")
+	require.ElementsMatch(t, []string{"/a.png", "/c.png"}, requested)
+}
+
+func TestCheck_CanceledSuccess(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The canceled checks aren't reported as broken assets
+	results := NewChecker().Check(ctx, []string{"http://127.0.0.1:1/logo.png"})
+	require.Empty(t, results)
+}
+
+func TestWriteJSON_EmptySuccess(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteJSON(&buf, nil)
+	require.NoError(t, err)
+	require.Equal(t, "[]\n", buf.String())
+}
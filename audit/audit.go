@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// Severity represents a severity of the issue.
+type Severity string
+
+// Possible severities of the issues.
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityNotice  Severity = "notice"
+)
+
+// Issue represents an issue found on one or more pages.
+type Issue struct {
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	URLs     []string `json:"urls"`
+}
+
+// WriteCSV writes the issues as CSV with a row per affected URL.
+func WriteCSV(w io.Writer, issues []Issue) error {
+	cw := csv.NewWriter(w)
+
+	data := [][]string{{"Severity", "Check", "Message", "URL"}}
+	for _, issue := range issues {
+		for _, URL := range issue.URLs {
+			data = append(data, []string{string(issue.Severity), issue.Check, issue.Message, URL})
+		}
+	}
+
+	return cw.WriteAll(data)
+}
+
+// WriteJSON writes the issues as JSON.
+func WriteJSON(w io.Writer, issues []Issue) error {
+	if issues == nil {
+		issues = []Issue{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(issues)
+}
+
+var severityOrder = map[Severity]int{
+	SeverityError:   0,
+	SeverityWarning: 1,
+	SeverityNotice:  2,
+}
+
+// sortIssues sorts the issues by severity, check and message.
+func sortIssues(issues []Issue) {
+	for _, issue := range issues {
+		sort.Strings(issue.URLs)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Severity != issues[j].Severity {
+			return severityOrder[issues[i].Severity] < severityOrder[issues[j].Severity]
+		}
+		if issues[i].Check != issues[j].Check {
+			return issues[i].Check < issues[j].Check
+		}
+
+		return issues[i].Message < issues[j].Message
+	})
+}
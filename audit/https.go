@@ -0,0 +1,200 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/demyanovs/urlcrawler/parser"
+)
+
+// HTTPSConfig represents a configuration of the HTTPS checks.
+// Certificates expiring in less than CertificateExpiryWarning are reported.
+type HTTPSConfig struct {
+	CertificateExpiryWarning time.Duration
+}
+
+// DefaultHTTPSConfig represents the default configuration of the HTTPS checks.
+var DefaultHTTPSConfig = HTTPSConfig{
+	CertificateExpiryWarning: 30 * 24 * time.Hour,
+}
+
+// HTTPCheckConfig represents a configuration of the requests of the plain HTTP versions of the HTTPS pages.
+// Delay is the delay between the requests. Allowed reports whether the URL can be requested,
+// e.g. by robots.txt, the disallowed URLs aren't requested. All the URLs are requested if it's nil.
+type HTTPCheckConfig struct {
+	Concurrency int
+	Timeout     time.Duration
+	Delay       time.Duration
+	Allowed     func(URL string) bool
+}
+
+// Certificate represents the certificate of the host.
+type Certificate struct {
+	Host string `json:"host"`
+	parser.TLS
+}
+
+// HTTPS runs HTTPS hygiene checks over successfully crawled pages.
+// httpReachable are the plain HTTP URLs of the HTTPS pages which respond without redirect to HTTPS.
+func HTTPS(pages parser.PagesData, httpReachable []string, config HTTPSConfig) []Issue {
+	checks := newIssues()
+	crawled := make(map[string]bool)
+
+	for _, p := range pages {
+		if p.StatusCode != http.StatusOK {
+			continue
+		}
+
+		crawled[p.URL] = true
+		if !strings.HasPrefix(p.URL, "https://") {
+			continue
+		}
+
+		for _, a := range p.Assets {
+			if strings.HasPrefix(a.URL, "http://") {
+				checks.add("mixed_content", SeverityError, fmt.Sprintf("HTTPS page loads HTTP subresource: %s", a.URL), p.URL)
+			}
+		}
+
+		for _, l := range p.HTTPLinks {
+			checks.add("http_link", SeverityWarning, fmt.Sprintf("HTTPS page links to HTTP page: %s", l), p.URL)
+		}
+
+		if p.Headers.Get("Strict-Transport-Security") == "" {
+			checks.add("missing_hsts", SeverityWarning, "Strict-Transport-Security header is missing", p.URL)
+		}
+	}
+
+	// Pages crawled over both schemes, e.g. found by absolute links
+	for URL := range crawled {
+		if strings.HasPrefix(URL, "http://") && crawled["https://"+strings.TrimPrefix(URL, "http://")] {
+			checks.add("http_and_https", SeverityWarning, "Page is reachable over both HTTP and HTTPS", "https://"+strings.TrimPrefix(URL, "http://"))
+		}
+	}
+
+	for _, URL := range httpReachable {
+		HTTPSURL := "https://" + strings.TrimPrefix(URL, "http://")
+		if !crawled[URL] {
+			checks.add("http_and_https", SeverityWarning, "Page is reachable over both HTTP and HTTPS", HTTPSURL)
+		}
+	}
+
+	now := time.Now()
+	for _, c := range Certificates(pages) {
+		expires := c.NotAfter.Format(time.DateOnly)
+		switch {
+		case now.After(c.NotAfter):
+			checks.add("certificate_expired", SeverityError, fmt.Sprintf("Certificate of %s expired on %s", c.Host, expires), "https://"+c.Host+"/")
+		case c.NotAfter.Sub(now) < config.CertificateExpiryWarning:
+			checks.add("certificate_expiring", SeverityWarning, fmt.Sprintf("Certificate of %s expires on %s", c.Host, expires), "https://"+c.Host+"/")
+		}
+	}
+
+	return checks.list()
+}
+
+// Certificates returns the certificates of the crawled hosts sorted by the host.
+func Certificates(pages parser.PagesData) []Certificate {
+	var certificates []Certificate
+	seen := make(map[string]bool)
+	for _, p := range pages {
+		if p.TLS == nil {
+			continue
+		}
+
+		u, err := url.Parse(p.URL)
+		if err != nil || seen[u.Host] {
+			continue
+		}
+
+		seen[u.Host] = true
+		certificates = append(certificates, Certificate{Host: u.Host, TLS: *p.TLS})
+	}
+
+	sort.Slice(certificates, func(i, j int) bool {
+		return certificates[i].Host < certificates[j].Host
+	})
+
+	return certificates
+}
+
+// HTTPReachable requests the plain HTTP versions of the successfully crawled HTTPS pages
+// and returns the URLs which respond with 2xx instead of redirecting to HTTPS.
+// It stops when the context is done and returns the URLs found by then.
+func HTTPReachable(ctx context.Context, pages parser.PagesData, config HTTPCheckConfig) []string {
+	client := &http.Client{
+		Timeout: config.Timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	concurrency := config.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var reachable []string
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	started := false
+
+	for _, p := range pages {
+		if p.StatusCode != http.StatusOK || !strings.HasPrefix(p.URL, "https://") {
+			continue
+		}
+
+		URL := "http://" + strings.TrimPrefix(p.URL, "https://")
+		if config.Allowed != nil && !config.Allowed(URL) {
+			continue
+		}
+
+		if started && config.Delay > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(config.Delay):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		started = true
+
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(URL string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, URL, nil)
+			if err != nil {
+				return
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				return
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				mu.Lock()
+				reachable = append(reachable, URL)
+				mu.Unlock()
+			}
+		}(URL)
+	}
+
+	wg.Wait()
+	sort.Strings(reachable)
+
+	return reachable
+}
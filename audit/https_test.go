@@ -0,0 +1,161 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPS_Success(t *testing.T) {
+	hsts := http.Header{"Strict-Transport-Security": {"max-age=31536000"}}
+	pages := parser.PagesData{
+		{
+			URL:        "https://example.com/",
+			StatusCode: 200,
+			Headers:    hsts,
+			Assets: []parser.Asset{
+				{URL: "http://example.com/app.js", Type: parser.AssetScript},
+				{URL: "https://example.com/main.css", Type: parser.AssetStylesheet},
+			},
+			HTTPLinks: []string{"http://partner.com/"},
+			TLS:       &parser.TLS{NotAfter: time.Now().Add(10 * 24 * time.Hour)},
+		},
+		{
+			URL:        "https://example.com/a",
+			StatusCode: 200,
+			Assets:     []parser.Asset{{URL: "http://example.com/app.js", Type: parser.AssetScript}},
+			TLS:        &parser.TLS{NotAfter: time.Now().Add(10 * 24 * time.Hour)},
+		},
+		{URL: "http://example.com/a", StatusCode: 200},
+		{URL: "https://example.com/missing", StatusCode: 404},
+		{
+			URL:        "https://old.example.com/",
+			StatusCode: 200,
+			Headers:    hsts,
+			TLS:        &parser.TLS{NotAfter: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	issues := HTTPS(pages, []string{"http://example.com/", "http://example.com/a"}, DefaultHTTPSConfig)
+
+	expires := time.Now().Add(10 * 24 * time.Hour).Format(time.DateOnly)
+	require.Equal(t, []Issue{
+		{
+			Check:    "certificate_expired",
+			Severity: SeverityError,
+			Message:  "Certificate of old.example.com expired on 2020-01-02",
+			URLs:     []string{"https://old.example.com/"},
+		},
+		{
+			Check:    "mixed_content",
+			Severity: SeverityError,
+			Message:  "HTTPS page loads HTTP subresource: http://example.com/app.js",
+			URLs:     []string{"https://example.com/", "https://example.com/a"},
+		},
+		{
+			Check:    "certificate_expiring",
+			Severity: SeverityWarning,
+			Message:  "Certificate of example.com expires on " + expires,
+			URLs:     []string{"https://example.com/"},
+		},
+		{
+			Check:    "http_and_https",
+			Severity: SeverityWarning,
+			Message:  "Page is reachable over both HTTP and HTTPS",
+			URLs:     []string{"https://example.com/", "https://example.com/a"},
+		},
+		{
+			Check:    "http_link",
+			Severity: SeverityWarning,
+			Message:  "HTTPS page links to HTTP page: http://partner.com/",
+			URLs:     []string{"https://example.com/"},
+		},
+		{
+			Check:    "missing_hsts",
+			Severity: SeverityWarning,
+			Message:  "Strict-Transport-Security header is missing",
+			URLs:     []string{"https://example.com/a"},
+		},
+	}, issues)
+}
+
+func TestCertificates_Success(t *testing.T) {
+	certificates := Certificates(parser.PagesData{
+		{URL: "https://www.example.com/", TLS: &parser.TLS{Issuer: "CN=R3"}},
+		{URL: "https://example.com/", TLS: &parser.TLS{Issuer: "CN=R3"}},
+		{URL: "https://example.com/a", TLS: &parser.TLS{Issuer: "CN=R3"}},
+		{URL: "http://example.com/"},
+	})
+
+	require.Equal(t, []Certificate{
+		{Host: "example.com", TLS: parser.TLS{Issuer: "CN=R3"}},
+		{Host: "www.example.com", TLS: parser.TLS{Issuer: "CN=R3"}},
+	}, certificates)
+}
+
+func TestHTTPReachable_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/secure" {
+			http.Redirect(w, r, "https://"+r.Host+r.URL.Path, http.StatusMovedPermanently)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	host := "https://" + strings.TrimPrefix(server.URL, "http://")
+	reachable := HTTPReachable(context.Background(), parser.PagesData{
+		{URL: host + "/secure", StatusCode: 200},
+		{URL: host + "/plain", StatusCode: 200},
+		{URL: host + "/missing", StatusCode: 404},
+	}, HTTPCheckConfig{Concurrency: 2, Timeout: time.Second})
+
+	require.Equal(t, []string{server.URL + "/plain"}, reachable)
+}
+
+func TestHTTPReachable_DelayAllowedSuccess(t *testing.T) {
+	var mu sync.Mutex
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requested = append(requested, r.URL.Path)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	host := "https://" + strings.TrimPrefix(server.URL, "http://")
+	pages := parser.PagesData{
+		{URL: host + "/a", StatusCode: 200},
+		{URL: host + "/private/b", StatusCode: 200},
+		{URL: host + "/c", StatusCode: 200},
+	}
+
+	startedAt := time.Now()
+	reachable := HTTPReachable(context.Background(), pages, HTTPCheckConfig{
+		Concurrency: 2,
+		Timeout:     time.Second,
+		Delay:       50 * time.Millisecond,
+		Allowed: func(URL string) bool {
+			return !strings.HasPrefix(URL, server.URL+"/private/")
+		},
+	})
+	require.GreaterOrEqual(t, time.Since(startedAt), 50*time.Millisecond)
+
+	// The disallowed page isn't requested
+	require.Equal(t, []string{server.URL + "/a", server.URL + "/c"}, reachable)
+	require.ElementsMatch(t, []string{"/a", "/c"}, requested)
+
+	// Nothing is requested after the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Empty(t, HTTPReachable(ctx, pages, HTTPCheckConfig{Timeout: time.Second}))
+	require.Len(t, requested, 2)
+}
@@ -0,0 +1,270 @@
+package audit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/demyanovs/urlcrawler/parser"
+)
+
+var (
+	regExVersion = regexp.MustCompile(`\d+(\.\d+)+`)
+
+	// disclosureHeaders represents the headers which disclose the server technology.
+	disclosureHeaders = []string{"X-Powered-By", "X-AspNet-Version", "X-AspNetMvc-Version", "X-Generator", "X-Runtime"}
+
+	referrerPolicies = []string{
+		"no-referrer", "no-referrer-when-downgrade", "origin", "origin-when-cross-origin",
+		"same-origin", "strict-origin", "strict-origin-when-cross-origin", "unsafe-url",
+	}
+)
+
+// SecurityIssue represents an issue of the security headers on the host.
+// AllPages is set if all the checked pages of the host are affected,
+// otherwise URLs lists the affected pages.
+type SecurityIssue struct {
+	Host     string   `json:"host"`
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	AllPages bool     `json:"all pages"`
+	URLs     []string `json:"urls,omitempty"`
+}
+
+// securityIssues groups affected pages by the host, the check and the message
+// and counts the checked pages of the hosts.
+type securityIssues struct {
+	issues  map[string]*issues
+	checked map[string]map[string]int
+}
+
+// Security checks the security headers of the successfully crawled pages and aggregates the issues per host.
+func Security(pages parser.PagesData) []SecurityIssue {
+	s := &securityIssues{
+		issues:  make(map[string]*issues),
+		checked: make(map[string]map[string]int),
+	}
+
+	for _, p := range pages {
+		if p.StatusCode != http.StatusOK {
+			continue
+		}
+
+		u, err := url.Parse(p.URL)
+		if err != nil {
+			continue
+		}
+
+		s.check(u.Host, u.Scheme == "https", p)
+	}
+
+	return s.list()
+}
+
+func (s *securityIssues) check(host string, https bool, p parser.PageData) {
+	is, ok := s.issues[host]
+	if !ok {
+		is = newIssues()
+		s.issues[host] = is
+		s.checked[host] = make(map[string]int)
+	}
+
+	h := p.Headers
+	checked := func(checks ...string) {
+		for _, check := range checks {
+			s.checked[host][check]++
+		}
+	}
+
+	if isHTML(p) {
+		csp := h.Get("Content-Security-Policy")
+		checked("csp", "x_frame_options", "referrer_policy", "permissions_policy")
+
+		switch {
+		case csp == "" && h.Get("Content-Security-Policy-Report-Only") != "":
+			is.add("csp", SeverityWarning, "Content-Security-Policy is only in report-only mode", p.URL)
+		case csp == "":
+			is.add("csp", SeverityWarning, "Content-Security-Policy header is missing", p.URL)
+		default:
+			for _, message := range cspProblems(csp) {
+				is.add("csp", SeverityWarning, message, p.URL)
+			}
+		}
+
+		xfo := strings.ToUpper(strings.TrimSpace(h.Get("X-Frame-Options")))
+		switch {
+		case xfo == "" && !strings.Contains(strings.ToLower(csp), "frame-ancestors"):
+			is.add("x_frame_options", SeverityWarning, "X-Frame-Options header is missing and CSP has no frame-ancestors", p.URL)
+		case xfo != "" && xfo != "DENY" && xfo != "SAMEORIGIN":
+			is.add("x_frame_options", SeverityWarning, fmt.Sprintf("X-Frame-Options has invalid value: %s", h.Get("X-Frame-Options")), p.URL)
+		}
+
+		referrerPolicy := strings.ToLower(strings.TrimSpace(h.Get("Referrer-Policy")))
+		if referrerPolicy == "" {
+			is.add("referrer_policy", SeverityNotice, "Referrer-Policy header is missing", p.URL)
+		} else {
+			// The last valid policy of the list is applied
+			policy := ""
+			for _, v := range strings.Split(referrerPolicy, ",") {
+				if v = strings.TrimSpace(v); slices.Contains(referrerPolicies, v) {
+					policy = v
+				}
+			}
+
+			switch policy {
+			case "":
+				is.add("referrer_policy", SeverityWarning, fmt.Sprintf("Referrer-Policy has invalid value: %s", h.Get("Referrer-Policy")), p.URL)
+			case "unsafe-url":
+				is.add("referrer_policy", SeverityWarning, "Referrer-Policy unsafe-url leaks full URLs to other origins", p.URL)
+			}
+		}
+
+		if h.Get("Permissions-Policy") == "" {
+			is.add("permissions_policy", SeverityNotice, "Permissions-Policy header is missing", p.URL)
+		}
+	}
+
+	checked("x_content_type_options", "disclosure", "cookies")
+
+	if xcto := h.Get("X-Content-Type-Options"); xcto == "" {
+		is.add("x_content_type_options", SeverityWarning, "X-Content-Type-Options header is missing", p.URL)
+	} else if !strings.EqualFold(strings.TrimSpace(xcto), "nosniff") {
+		is.add("x_content_type_options", SeverityWarning, fmt.Sprintf("X-Content-Type-Options has invalid value: %s", xcto), p.URL)
+	}
+
+	if server := h.Get("Server"); regExVersion.MatchString(server) {
+		is.add("disclosure", SeverityNotice, fmt.Sprintf("Server header discloses the version: %s", server), p.URL)
+	}
+
+	for _, name := range disclosureHeaders {
+		if value := h.Get(name); value != "" {
+			is.add("disclosure", SeverityNotice, fmt.Sprintf("%s header discloses the technology: %s", name, value), p.URL)
+		}
+	}
+
+	for _, c := range (&http.Response{Header: h}).Cookies() {
+		if https && !c.Secure {
+			is.add("cookies", SeverityWarning, fmt.Sprintf("Cookie %s has no Secure flag", c.Name), p.URL)
+		}
+		if !c.HttpOnly {
+			is.add("cookies", SeverityNotice, fmt.Sprintf("Cookie %s has no HttpOnly flag", c.Name), p.URL)
+		}
+
+		switch {
+		case c.SameSite == http.SameSiteDefaultMode:
+			is.add("cookies", SeverityNotice, fmt.Sprintf("Cookie %s has no SameSite attribute", c.Name), p.URL)
+		case c.SameSite == http.SameSiteNoneMode && !c.Secure:
+			is.add("cookies", SeverityError, fmt.Sprintf("Cookie %s has SameSite=None without Secure flag", c.Name), p.URL)
+		}
+	}
+}
+
+func (s *securityIssues) list() []SecurityIssue {
+	var list []SecurityIssue
+	for host, is := range s.issues {
+		for _, issue := range is.list() {
+			si := SecurityIssue{
+				Host:     host,
+				Check:    issue.Check,
+				Severity: issue.Severity,
+				Message:  issue.Message,
+				URLs:     issue.URLs,
+			}
+
+			if len(issue.URLs) == s.checked[host][issue.Check] {
+				si.AllPages = true
+				si.URLs = nil
+			}
+
+			list = append(list, si)
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Host != list[j].Host {
+			return list[i].Host < list[j].Host
+		}
+		if list[i].Severity != list[j].Severity {
+			return severityOrder[list[i].Severity] < severityOrder[list[j].Severity]
+		}
+		if list[i].Check != list[j].Check {
+			return list[i].Check < list[j].Check
+		}
+
+		return list[i].Message < list[j].Message
+	})
+
+	return list
+}
+
+// cspProblems returns the unsafe sources allowed for scripts by the Content-Security-Policy.
+func cspProblems(csp string) []string {
+	directives := make(map[string][]string)
+	for _, d := range strings.Split(strings.ToLower(csp), ";") {
+		fields := strings.Fields(d)
+		if len(fields) > 0 {
+			directives[fields[0]] = fields[1:]
+		}
+	}
+
+	sources, ok := directives["script-src"]
+	if !ok {
+		sources, ok = directives["default-src"]
+	}
+	if !ok {
+		return []string{"Content-Security-Policy doesn't restrict scripts (no script-src or default-src)"}
+	}
+
+	var problems []string
+	for _, source := range sources {
+		switch source {
+		case "'unsafe-inline'", "'unsafe-eval'":
+			problems = append(problems, fmt.Sprintf("Content-Security-Policy allows %s scripts", strings.Trim(source, "'")))
+		case "*", "http:", "https:", "data:":
+			problems = append(problems, fmt.Sprintf("Content-Security-Policy allows scripts from %s", source))
+		}
+	}
+
+	return problems
+}
+
+// WriteSecurityCSV writes the security issues as CSV with a row per affected URL,
+// or with the "*" URL if all the pages of the host are affected.
+func WriteSecurityCSV(w io.Writer, issues []SecurityIssue) error {
+	cw := csv.NewWriter(w)
+
+	data := [][]string{{"Host", "Severity", "Check", "Message", "AllPages", "URL"}}
+	for _, issue := range issues {
+		if issue.AllPages {
+			data = append(data, []string{issue.Host, string(issue.Severity), issue.Check, issue.Message, strconv.FormatBool(true), "*"})
+			continue
+		}
+
+		for _, URL := range issue.URLs {
+			data = append(data, []string{issue.Host, string(issue.Severity), issue.Check, issue.Message, strconv.FormatBool(false), URL})
+		}
+	}
+
+	return cw.WriteAll(data)
+}
+
+// WriteSecurityJSON writes the security issues as JSON.
+func WriteSecurityJSON(w io.Writer, issues []SecurityIssue) error {
+	if issues == nil {
+		issues = []SecurityIssue{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(issues)
+}
@@ -0,0 +1,138 @@
+package audit
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/stretchr/testify/require"
+)
+
+var secureHeaders = http.Header{
+	"Content-Security-Policy": {"default-src 'self'; frame-ancestors 'none'"},
+	"X-Content-Type-Options":  {"nosniff"},
+	"Referrer-Policy":         {"strict-origin-when-cross-origin"},
+	"Permissions-Policy":      {"camera=()"},
+	"Server":                  {"nginx"},
+}
+
+func TestSecurity_Success(t *testing.T) {
+	headers := secureHeaders.Clone()
+	headers.Set("Server", "nginx/1.25.3")
+	headers.Set("X-Powered-By", "PHP/8.2")
+
+	loginHeaders := headers.Clone()
+	loginHeaders.Set("Content-Security-Policy", "script-src 'self' 'unsafe-inline'")
+	loginHeaders.Set("Referrer-Policy", "unsafe-url")
+	loginHeaders.Add("Set-Cookie", "session=1; Path=/; HttpOnly; SameSite=Lax")
+	loginHeaders.Add("Set-Cookie", "tracking=1; SameSite=None")
+
+	pages := parser.PagesData{
+		{URL: "https://example.com/", StatusCode: 200, ContentType: "text/html", Headers: headers},
+		{URL: "https://example.com/login", StatusCode: 200, ContentType: "text/html", Headers: loginHeaders},
+		{URL: "https://example.com/missing", StatusCode: 404, ContentType: "text/html"},
+		{URL: "https://blog.example.com/", StatusCode: 200, ContentType: "text/html", Headers: secureHeaders},
+		{
+			URL:         "https://blog.example.com/feed",
+			StatusCode:  200,
+			ContentType: "application/rss+xml",
+			Headers:     http.Header{"X-Content-Type-Options": {"nosniff"}, "X-Frame-Options": {"ALLOW-FROM https://a.com"}},
+		},
+	}
+
+	issues := Security(pages)
+	require.Equal(t, []SecurityIssue{
+		{
+			Host:     "example.com",
+			Check:    "cookies",
+			Severity: SeverityError,
+			Message:  "Cookie tracking has SameSite=None without Secure flag",
+			URLs:     []string{"https://example.com/login"},
+		},
+		{
+			Host:     "example.com",
+			Check:    "cookies",
+			Severity: SeverityWarning,
+			Message:  "Cookie session has no Secure flag",
+			URLs:     []string{"https://example.com/login"},
+		},
+		{
+			Host:     "example.com",
+			Check:    "cookies",
+			Severity: SeverityWarning,
+			Message:  "Cookie tracking has no Secure flag",
+			URLs:     []string{"https://example.com/login"},
+		},
+		{
+			Host:     "example.com",
+			Check:    "csp",
+			Severity: SeverityWarning,
+			Message:  "Content-Security-Policy allows unsafe-inline scripts",
+			URLs:     []string{"https://example.com/login"},
+		},
+		{
+			Host:     "example.com",
+			Check:    "referrer_policy",
+			Severity: SeverityWarning,
+			Message:  "Referrer-Policy unsafe-url leaks full URLs to other origins",
+			URLs:     []string{"https://example.com/login"},
+		},
+		{
+			Host:     "example.com",
+			Check:    "x_frame_options",
+			Severity: SeverityWarning,
+			Message:  "X-Frame-Options header is missing and CSP has no frame-ancestors",
+			URLs:     []string{"https://example.com/login"},
+		},
+		{
+			Host:     "example.com",
+			Check:    "cookies",
+			Severity: SeverityNotice,
+			Message:  "Cookie tracking has no HttpOnly flag",
+			URLs:     []string{"https://example.com/login"},
+		},
+		{
+			Host:     "example.com",
+			Check:    "disclosure",
+			Severity: SeverityNotice,
+			Message:  "Server header discloses the version: nginx/1.25.3",
+			AllPages: true,
+		},
+		{
+			Host:     "example.com",
+			Check:    "disclosure",
+			Severity: SeverityNotice,
+			Message:  "X-Powered-By header discloses the technology: PHP/8.2",
+			AllPages: true,
+		},
+	}, issues)
+
+	var buf bytes.Buffer
+	err := WriteSecurityCSV(&buf, issues[len(issues)-1:])
+	require.NoError(t, err)
+	require.Equal(t, "Host,Severity,Check,Message,AllPages,URL\n"+
+		"example.com,notice,disclosure,X-Powered-By header discloses the technology: PHP/8.2,true,*\n", buf.String())
+}
+
+func TestSecurity_MissingHeadersSuccess(t *testing.T) {
+	issues := Security(parser.PagesData{
+		{URL: "http://example.com/", StatusCode: 200, Headers: http.Header{"Content-Security-Policy-Report-Only": {"default-src 'self'"}}},
+		{URL: "http://example.com/a", StatusCode: 200, Headers: http.Header{"Content-Security-Policy": {"img-src *"}}},
+	})
+
+	var checks []string
+	for _, issue := range issues {
+		require.Equal(t, "example.com", issue.Host)
+		checks = append(checks, issue.Check+": "+issue.Message)
+	}
+
+	require.Equal(t, []string{
+		"csp: Content-Security-Policy doesn't restrict scripts (no script-src or default-src)",
+		"csp: Content-Security-Policy is only in report-only mode",
+		"x_content_type_options: X-Content-Type-Options header is missing",
+		"x_frame_options: X-Frame-Options header is missing and CSP has no frame-ancestors",
+		"permissions_policy: Permissions-Policy header is missing",
+		"referrer_policy: Referrer-Policy header is missing",
+	}, checks)
+}
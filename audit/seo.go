@@ -0,0 +1,138 @@
+package audit
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/demyanovs/urlcrawler/parser"
+)
+
+// SEOConfig represents a configuration of the SEO checks.
+type SEOConfig struct {
+	MaxTitleLength       int
+	MaxDescriptionLength int
+	MinWordCount         int
+}
+
+// DefaultSEOConfig represents the default configuration of the SEO checks.
+var DefaultSEOConfig = SEOConfig{
+	MaxTitleLength:       60,
+	MaxDescriptionLength: 160,
+	MinWordCount:         200,
+}
+
+// SEO runs on-page SEO checks over successfully parsed HTML pages.
+func SEO(pages parser.PagesData, config SEOConfig) []Issue {
+	checks := newIssues()
+	titles := make(map[string][]string)
+	descriptions := make(map[string][]string)
+
+	for _, p := range pages {
+		if p.StatusCode != http.StatusOK || !isHTML(p) {
+			continue
+		}
+
+		if p.Title == "" {
+			checks.add("missing_title", SeverityError, "Title is missing", p.URL)
+		} else {
+			titles[p.Title] = append(titles[p.Title], p.URL)
+			if len([]rune(p.Title)) > config.MaxTitleLength {
+				checks.add("title_too_long", SeverityWarning, fmt.Sprintf("Title is longer than %d characters", config.MaxTitleLength), p.URL)
+			}
+		}
+
+		if p.Desc == "" {
+			checks.add("missing_description", SeverityWarning, "Meta description is missing", p.URL)
+		} else {
+			descriptions[p.Desc] = append(descriptions[p.Desc], p.URL)
+			if len([]rune(p.Desc)) > config.MaxDescriptionLength {
+				checks.add("description_too_long", SeverityWarning, fmt.Sprintf("Meta description is longer than %d characters", config.MaxDescriptionLength), p.URL)
+			}
+		}
+
+		switch {
+		case len(p.H1) == 0:
+			checks.add("missing_h1", SeverityWarning, "H1 heading is missing", p.URL)
+		case len(p.H1) > 1:
+			checks.add("multiple_h1", SeverityNotice, "Page has multiple H1 headings", p.URL)
+		}
+
+		if p.ImagesNoAlt > 0 {
+			checks.add("missing_alt", SeverityWarning, "Images without alt text", p.URL)
+		}
+
+		if p.NoIndex {
+			checks.add("noindex", SeverityNotice, "Page is excluded from indexing by robots directives", p.URL)
+		}
+
+		if p.NoFollow {
+			checks.add("nofollow", SeverityNotice, "Links are not followed by robots directives", p.URL)
+		}
+
+		if p.Lang == "" {
+			checks.add("missing_lang", SeverityNotice, "Lang attribute of the html element is missing", p.URL)
+		}
+
+		if p.WordCount < config.MinWordCount {
+			checks.add("thin_content", SeverityWarning, fmt.Sprintf("Page has less than %d words", config.MinWordCount), p.URL)
+		}
+	}
+
+	for title, URLs := range titles {
+		if len(URLs) > 1 {
+			checks.add("duplicate_title", SeverityWarning, fmt.Sprintf("Duplicate title: %s", title), URLs...)
+		}
+	}
+
+	for desc, URLs := range descriptions {
+		if len(URLs) > 1 {
+			checks.add("duplicate_description", SeverityWarning, fmt.Sprintf("Duplicate meta description: %s", desc), URLs...)
+		}
+	}
+
+	return checks.list()
+}
+
+// issues groups affected URLs by the check and the message.
+type issues struct {
+	order []string
+	m     map[string]*Issue
+}
+
+func newIssues() *issues {
+	return &issues{
+		m: make(map[string]*Issue),
+	}
+}
+
+func (is *issues) add(check string, severity Severity, message string, URLs ...string) {
+	key := check + "\x00" + message
+	issue, ok := is.m[key]
+	if !ok {
+		issue = &Issue{
+			Check:    check,
+			Severity: severity,
+			Message:  message,
+		}
+		is.m[key] = issue
+		is.order = append(is.order, key)
+	}
+
+	issue.URLs = append(issue.URLs, URLs...)
+}
+
+func (is *issues) list() []Issue {
+	list := make([]Issue, 0, len(is.order))
+	for _, key := range is.order {
+		list = append(list, *is.m[key])
+	}
+
+	sortIssues(list)
+
+	return list
+}
+
+func isHTML(p parser.PageData) bool {
+	return p.ContentType == "" || strings.Contains(p.ContentType, "html")
+}
@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/stretchr/testify/require"
+)
+
+var seoPages = parser.PagesData{
+	{
+		URL:        "https://example.com/",
+		StatusCode: 200,
+		Title:      "Home",
+		Desc:       "Home page",
+		H1:         []string{"Home"},
+		Lang:       "en",
+		WordCount:  300,
+	},
+	{
+		URL:         "https://example.com/a",
+		StatusCode:  200,
+		Title:       "Home",
+		Desc:        strings.Repeat("a", 161),
+		H1:          []string{"A", "B"},
+		Lang:        "en",
+		WordCount:   10,
+		ImagesNoAlt: 2,
+		NoIndex:     true,
+		NoFollow:    true,
+	},
+	{
+		URL:        "https://example.com/b",
+		StatusCode: 200,
+		Title:      strings.Repeat("b", 61),
+		WordCount:  300,
+		NoIndex:    true,
+	},
+	{
+		URL:        "https://example.com/missing",
+		StatusCode: 404,
+	},
+	{
+		URL:         "https://example.com/file.pdf",
+		StatusCode:  200,
+		ContentType: "application/pdf",
+	},
+}
+
+func TestSEO_Success(t *testing.T) {
+	issues := SEO(seoPages, DefaultSEOConfig)
+
+	checks := make(map[string][]string)
+	for _, issue := range issues {
+		checks[issue.Check] = append(checks[issue.Check], issue.URLs...)
+	}
+
+	require.Equal(t, map[string][]string{
+		"missing_description":  {"https://example.com/b"},
+		"description_too_long": {"https://example.com/a"},
+		"title_too_long":       {"https://example.com/b"},
+		"duplicate_title":      {"https://example.com/", "https://example.com/a"},
+		"missing_h1":           {"https://example.com/b"},
+		"multiple_h1":          {"https://example.com/a"},
+		"missing_alt":          {"https://example.com/a"},
+		"noindex":              {"https://example.com/a", "https://example.com/b"},
+		"nofollow":             {"https://example.com/a"},
+		"missing_lang":         {"https://example.com/b"},
+		"thin_content":         {"https://example.com/a"},
+	}, checks)
+
+	require.Equal(t, SeverityWarning, issues[0].Severity)
+	require.Equal(t, SeverityNotice, issues[len(issues)-1].Severity)
+}
+
+func TestSEO_NoIssuesSuccess(t *testing.T) {
+	issues := SEO(seoPages[:1], DefaultSEOConfig)
+	require.Empty(t, issues)
+}
+
+func TestWriteCSV_Success(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, []Issue{
+		{Check: "missing_title", Severity: SeverityError, Message: "Title is missing", URLs: []string{"https://example.com/a", "https://example.com/b"}},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "Severity,Check,Message,URL\n"+
+		"error,missing_title,Title is missing,https://example.com/a\n"+
+		"error,missing_title,Title is missing,https://example.com/b\n", buf.String())
+}
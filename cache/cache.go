@@ -0,0 +1,401 @@
+// Package cache implements an on-disk cache of the raw HTTP responses to rerun the crawls
+// without hitting the site again or entirely offline.
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/demyanovs/urlcrawler/middleware"
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/demyanovs/urlcrawler/queue"
+)
+
+// Mode represents a mode of the cache.
+type Mode string
+
+// Supported modes of the cache.
+const (
+	// ModeOff disables the cache.
+	ModeOff Mode = "off"
+	// ModeReadWrite serves the cached responses and caches the fetched ones.
+	ModeReadWrite Mode = "read-write"
+	// ModeReadOnly serves the cached responses only regardless of their freshness to replay the crawl offline,
+	// the URLs which aren't cached result in ErrNotCached.
+	ModeReadOnly Mode = "read-only"
+)
+
+// Modes is the list of the supported modes.
+var Modes = []Mode{ModeOff, ModeReadWrite, ModeReadOnly}
+
+// ErrNotCached is returned in the read-only mode if the response isn't cached.
+var ErrNotCached = errors.New("response is not cached")
+
+// Cache represents an on-disk cache of the responses keyed by the normalized URL
+// and the values of the request headers listed in the Vary header of the response.
+// The freshness of the responses is checked by Cache-Control and Expires in the read-write mode
+// unless IgnoreCacheControl is set.
+type Cache struct {
+	Dir                string
+	Mode               Mode
+	IgnoreCacheControl bool
+	now                func() time.Time
+}
+
+// meta represents the metadata of the cached response.
+type meta struct {
+	URL      string    `json:"url"`
+	StoredAt time.Time `json:"stored_at"`
+}
+
+// index represents the names of the headers the cached responses of the URL vary by.
+type index struct {
+	Vary []string `json:"vary,omitempty"`
+}
+
+// New creates a new Cache in the directory.
+func New(dir string, mode Mode) (*Cache, error) {
+	if !slices.Contains(Modes, mode) {
+		return nil, fmt.Errorf("unsupported cache mode: %s. Supported modes: %v", mode, Modes)
+	}
+
+	if mode != ModeOff {
+		err := os.MkdirAll(dir, 0o755)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Cache{
+		Dir:  dir,
+		Mode: mode,
+		now:  time.Now,
+	}, nil
+}
+
+// Middleware returns the middleware of the queue which serves the pages from the cache.
+func (c *Cache) Middleware() queue.Middleware {
+	return middleware.Fetch(c.Fetch)
+}
+
+// Fetch returns the cached response of the request if it's fresh, otherwise fetches it with next
+// and caches it in the read-write mode. The cached responses have no timing and TLS details.
+func (c *Cache) Fetch(ctx context.Context, req *queue.Request, next middleware.FetchFunc) (*http.Response, parser.Timing, error) {
+	if c.Mode == ModeOff {
+		return next(ctx, req)
+	}
+
+	resp, err := c.Get(req.URL, req.Header)
+	if err != nil {
+		return nil, parser.Timing{}, err
+	}
+	if resp != nil {
+		return resp, parser.Timing{}, nil
+	}
+
+	if c.Mode == ModeReadOnly {
+		return nil, parser.Timing{}, fmt.Errorf("%w: %s", ErrNotCached, req.URL)
+	}
+
+	resp, timing, err := next(ctx, req)
+	if err != nil {
+		return resp, timing, err
+	}
+
+	err = c.Put(req.URL, req.Header, resp)
+	if err != nil {
+		return resp, timing, fmt.Errorf("can't cache response: %s", err)
+	}
+
+	return resp, timing, nil
+}
+
+// Get returns the cached response of the URL with the request headers, or nil if it isn't cached
+// or is stale in the read-write mode.
+func (c *Cache) Get(URL string, header http.Header) (*http.Response, error) {
+	normalizedURL := Normalize(URL)
+
+	var idx index
+	found, err := readJSON(c.path(normalizedURL, ".json"), &idx)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	file, err := os.Open(c.path(key(normalizedURL, idx.Vary, header), ".http"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache entry of %s: %s", URL, err)
+	}
+
+	var m meta
+	err = json.Unmarshal(line, &m)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache entry of %s: %s", URL, err)
+	}
+
+	httpReq, err := http.NewRequest(http.MethodGet, m.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.ReadResponse(reader, httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache entry of %s: %s", URL, err)
+	}
+
+	var body bytes.Buffer
+	_, err = body.ReadFrom(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Mode != ModeReadOnly && !c.IgnoreCacheControl && !fresh(resp.Header, m.StoredAt, c.now()) {
+		return nil, nil
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body.Bytes()))
+	resp.ContentLength = int64(body.Len())
+
+	return resp, nil
+}
+
+// Put caches the response of the URL with the request headers.
+// The responses which must not be stored, partial and not modified responses aren't cached.
+// The body of the response can be read again after it.
+func (c *Cache) Put(URL string, header http.Header, resp *http.Response) error {
+	if resp.StatusCode == http.StatusNotModified || resp.StatusCode == http.StatusPartialContent {
+		return nil
+	}
+
+	vary := varyHeaders(resp.Header)
+	if slices.Contains(vary, "*") {
+		return nil
+	}
+
+	if !c.IgnoreCacheControl && slices.Contains(cacheControl(resp.Header), "no-store") {
+		return nil
+	}
+
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return err
+	}
+
+	finalURL := URL
+	if resp.Request != nil && resp.Request.URL != nil {
+		finalURL = resp.Request.URL.String()
+	}
+
+	line, err := json.Marshal(meta{URL: finalURL, StoredAt: c.now()})
+	if err != nil {
+		return err
+	}
+
+	normalizedURL := Normalize(URL)
+	err = writeFile(c.path(key(normalizedURL, vary, header), ".http"), append(append(line, '\n'), dump...))
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(index{Vary: vary})
+	if err != nil {
+		return err
+	}
+
+	return writeFile(c.path(normalizedURL, ".json"), data)
+}
+
+// Normalize normalizes the URL for the cache key: lowercases the scheme and the host,
+// removes the default port and the fragment and sorts the query parameters.
+func Normalize(URL string) string {
+	u, err := url.Parse(URL)
+	if err != nil {
+		return URL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if (u.Scheme == "http" && u.Port() == "80") || (u.Scheme == "https" && u.Port() == "443") {
+		u.Host = u.Hostname()
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.RawQuery = u.Query().Encode()
+
+	return u.String()
+}
+
+// path returns the path of the file of the cache key.
+func (c *Cache) path(key string, ext string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+
+	return filepath.Join(c.Dir, name[:2], name+ext)
+}
+
+// key returns the cache key of the normalized URL and the values of the vary headers.
+func key(normalizedURL string, vary []string, header http.Header) string {
+	var b strings.Builder
+	b.WriteString(normalizedURL)
+	for _, name := range vary {
+		b.WriteString("\n")
+		b.WriteString(name)
+		b.WriteString(": ")
+		b.WriteString(strings.Join(header.Values(name), ", "))
+	}
+
+	return b.String()
+}
+
+// varyHeaders returns the sorted canonical names of the headers listed in the Vary header.
+func varyHeaders(header http.Header) []string {
+	var names []string
+	for _, v := range header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+
+			name = http.CanonicalHeaderKey(name)
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// cacheControl returns the lowercased directives of the Cache-Control header.
+func cacheControl(header http.Header) []string {
+	var directives []string
+	for _, v := range header.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			if d = strings.ToLower(strings.TrimSpace(d)); d != "" {
+				directives = append(directives, d)
+			}
+		}
+	}
+
+	return directives
+}
+
+// fresh reports whether the response stored at the time is fresh by Cache-Control, Expires
+// or heuristically by 10% of the time since Last-Modified. Responses without the freshness information are stale.
+func fresh(header http.Header, storedAt time.Time, now time.Time) bool {
+	age := now.Sub(storedAt)
+
+	for _, d := range cacheControl(header) {
+		if d == "no-cache" {
+			return false
+		}
+	}
+
+	for _, d := range cacheControl(header) {
+		if v, ok := strings.CutPrefix(d, "max-age="); ok {
+			seconds, err := strconv.Atoi(strings.Trim(v, `"`))
+			if err != nil {
+				return false
+			}
+
+			return age < time.Duration(seconds)*time.Second
+		}
+	}
+
+	if expires := header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return false
+		}
+
+		date, err := http.ParseTime(header.Get("Date"))
+		if err != nil {
+			date = storedAt
+		}
+
+		return age < t.Sub(date)
+	}
+
+	if lastModified := header.Get("Last-Modified"); lastModified != "" {
+		t, err := http.ParseTime(lastModified)
+		if err != nil {
+			return false
+		}
+
+		return age < storedAt.Sub(t)/10
+	}
+
+	return false
+}
+
+// readJSON reads the JSON file into v. It returns false if the file doesn't exist.
+func readJSON(filePath string, v any) (bool, error) {
+	content, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, json.Unmarshal(content, v)
+}
+
+// writeFile writes the file atomically, so the concurrent readers never see a partial file.
+func writeFile(filePath string, data []byte) error {
+	err := os.MkdirAll(filepath.Dir(filePath), 0o755)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filePath)
+}
@@ -0,0 +1,198 @@
+package cache
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/demyanovs/urlcrawler/fetcher"
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/demyanovs/urlcrawler/queue"
+	"github.com/stretchr/testify/require"
+)
+
+const testCacheDir = "test_cache"
+
+func newTestServer(requests *atomic.Int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/fresh", http.StatusMovedPermanently)
+			return
+		case "/fresh":
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store")
+		case "/lang":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "Accept-Language")
+			_, _ = w.Write([]byte("lang: " + r.Header.Get("Accept-Language")))
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><head><title>" + r.URL.Path + "</title></head></html>"))
+	}))
+}
+
+func fetch(ctx context.Context, req *queue.Request) (*http.Response, parser.Timing, error) {
+	return fetcher.New().Fetch(ctx, req.URL, req.Header)
+}
+
+func get(t *testing.T, c *Cache, URL string, header http.Header) (*http.Response, string) {
+	if header == nil {
+		header = http.Header{}
+	}
+
+	resp, _, err := c.Fetch(context.Background(), &queue.Request{URL: URL, Header: header}, fetch)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp, string(body)
+}
+
+func TestFetch_ReadWriteSuccess(t *testing.T) {
+	defer os.RemoveAll(testCacheDir)
+
+	var requests atomic.Int64
+	server := newTestServer(&requests)
+	defer server.Close()
+
+	c, err := New(testCacheDir, ModeReadWrite)
+	require.NoError(t, err)
+
+	// Redirects are cached with the final URL
+	resp, body := get(t, c, server.URL+"/old", nil)
+	require.Equal(t, "<html><head><title>/fresh</title></head></html>", body)
+	require.Equal(t, int64(2), requests.Load())
+
+	resp, body = get(t, c, server.URL+"/old#top", nil)
+	require.Equal(t, "<html><head><title>/fresh</title></head></html>", body)
+	require.Equal(t, server.URL+"/fresh", resp.Request.URL.String())
+	require.Equal(t, "text/html", resp.Header.Get("Content-Type"))
+	require.Equal(t, int64(len(body)), resp.ContentLength)
+	require.Equal(t, int64(2), requests.Load())
+
+	// Stale after max-age
+	c.now = func() time.Time {
+		return time.Now().Add(2 * time.Minute)
+	}
+	get(t, c, server.URL+"/old", nil)
+	require.Equal(t, int64(4), requests.Load())
+}
+
+func TestFetch_CacheControlSuccess(t *testing.T) {
+	defer os.RemoveAll(testCacheDir)
+
+	var requests atomic.Int64
+	server := newTestServer(&requests)
+	defer server.Close()
+
+	c, err := New(testCacheDir, ModeReadWrite)
+	require.NoError(t, err)
+
+	// Responses without the freshness information are stale
+	get(t, c, server.URL+"/page", nil)
+	get(t, c, server.URL+"/page", nil)
+	require.Equal(t, int64(2), requests.Load())
+
+	get(t, c, server.URL+"/no-store", nil)
+	get(t, c, server.URL+"/no-store", nil)
+	require.Equal(t, int64(4), requests.Load())
+
+	c.IgnoreCacheControl = true
+	get(t, c, server.URL+"/page", nil)
+	require.Equal(t, int64(4), requests.Load())
+
+	get(t, c, server.URL+"/no-store", nil)
+	get(t, c, server.URL+"/no-store", nil)
+	require.Equal(t, int64(5), requests.Load())
+}
+
+func TestFetch_VarySuccess(t *testing.T) {
+	defer os.RemoveAll(testCacheDir)
+
+	var requests atomic.Int64
+	server := newTestServer(&requests)
+	defer server.Close()
+
+	c, err := New(testCacheDir, ModeReadWrite)
+	require.NoError(t, err)
+
+	_, body := get(t, c, server.URL+"/lang", http.Header{"Accept-Language": {"en"}})
+	require.Equal(t, "lang: en", body)
+	_, body = get(t, c, server.URL+"/lang", http.Header{"Accept-Language": {"de"}})
+	require.Equal(t, "lang: de", body)
+	require.Equal(t, int64(2), requests.Load())
+
+	_, body = get(t, c, server.URL+"/lang", http.Header{"Accept-Language": {"en"}})
+	require.Equal(t, "lang: en", body)
+	require.Equal(t, int64(2), requests.Load())
+}
+
+func TestFetch_ReadOnlySuccess(t *testing.T) {
+	defer os.RemoveAll(testCacheDir)
+
+	var requests atomic.Int64
+	server := newTestServer(&requests)
+	defer server.Close()
+
+	c, err := New(testCacheDir, ModeReadWrite)
+	require.NoError(t, err)
+	get(t, c, server.URL+"/page", nil)
+
+	// The page without the cache headers is stale, but it's replayed in the read-only mode
+	c, err = New(testCacheDir, ModeReadOnly)
+	require.NoError(t, err)
+	c.now = func() time.Time { return time.Now().Add(24 * time.Hour) }
+
+	_, body := get(t, c, server.URL+"/page", nil)
+	require.Equal(t, "<html><head><title>/page</title></head></html>", body)
+	require.Equal(t, int64(1), requests.Load())
+
+	_, _, err = c.Fetch(context.Background(), &queue.Request{URL: server.URL + "/missing", Header: http.Header{}}, fetch)
+	require.ErrorIs(t, err, ErrNotCached)
+	require.Equal(t, int64(1), requests.Load())
+}
+
+func TestFetch_OffSuccess(t *testing.T) {
+	var requests atomic.Int64
+	server := newTestServer(&requests)
+	defer server.Close()
+
+	c, err := New(testCacheDir, ModeOff)
+	require.NoError(t, err)
+
+	get(t, c, server.URL+"/fresh", nil)
+	get(t, c, server.URL+"/fresh", nil)
+	require.Equal(t, int64(2), requests.Load())
+	require.NoDirExists(t, testCacheDir)
+}
+
+func TestNew_ModeError(t *testing.T) {
+	_, err := New(testCacheDir, "write-only")
+	require.EqualError(t, err, "unsupported cache mode: write-only. Supported modes: [off read-write read-only]")
+}
+
+func TestNormalize_Success(t *testing.T) {
+	tests := map[string]string{
+		"HTTPS://Example.com:443":          "https://example.com/",
+		"http://example.com:80/a?b=2&a=1":  "http://example.com/a?a=1&b=2",
+		"http://example.com:8080/a#top":    "http://example.com:8080/a",
+		"https://example.com/a%20b?q=x+y":  "https://example.com/a%20b?q=x+y",
+		"https://example.com/?utm=1&utm=0": "https://example.com/?utm=1&utm=0",
+	}
+
+	for URL, expected := range tests {
+		require.Equal(t, expected, Normalize(URL), URL)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/demyanovs/urlcrawler/diff"
+	"github.com/demyanovs/urlcrawler/report"
+)
+
+const (
+	diffFormatText = "text"
+	diffFormatCSV  = "csv"
+	diffFormatJSON = "json"
+)
+
+// exitCodeRegressions is returned by the diff command when new 4xx/5xx pages appear.
+const exitCodeRegressions = 2
+
+func runDiff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := flags.String("format", diffFormatText, "Output format (text, csv, json)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s diff [options] old-report new-report\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	oldPages, err := report.Read(flags.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	newPages, err := report.Read(flags.Arg(1))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	result := diff.Compare(oldPages, newPages)
+
+	switch *format {
+	case diffFormatText:
+		err = diff.WriteText(os.Stdout, result)
+	case diffFormatCSV:
+		err = diff.WriteCSV(os.Stdout, result)
+	case diffFormatJSON:
+		err = diff.WriteJSON(os.Stdout, result)
+	default:
+		err = fmt.Errorf("unsupported diff format: %s", *format)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if result.HasRegressions() {
+		os.Exit(exitCodeRegressions)
+	}
+}
@@ -0,0 +1,299 @@
+// Package crawler provides the crawler to embed into Go programs.
+// The crawler is configured with the functional options, reports the crawling events to the hooks
+// and returns the errors instead of exiting.
+//
+//	c, err := crawler.New("https://example.com",
+//		crawler.WithDepth(2),
+//		crawler.OnPage(func(page parser.PageData) {
+//			fmt.Println(page.URL, page.StatusCode)
+//		}),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//
+//	err = c.Run(ctx)
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/demyanovs/urlcrawler/queue"
+	"github.com/demyanovs/urlcrawler/render"
+)
+
+// Default configuration of the crawler, the same as of the command-line tool.
+const (
+	DefaultConcurrency = 50
+	DefaultBulkSize    = 30
+	DefaultDelay       = time.Second
+	DefaultTimeout     = 5 * time.Second
+	DefaultBotName     = "urlcrawler"
+)
+
+// Stats represents the statistics of the crawl.
+type Stats = queue.Stats
+
+// Crawler represents a crawler of the site.
+type Crawler struct {
+	config      queue.ConfigType
+	hooks       queue.Hooks
+	reporter    queue.Reporter
+	logger      *slog.Logger
+	robots      queue.RobotsData
+	state       queue.CrawlState
+	middlewares []queue.Middleware
+	queue       *queue.Queue
+}
+
+// Option represents an option of the crawler.
+type Option func(c *Crawler)
+
+// New creates a new Crawler of the site starting from the URL.
+// Nothing is logged and robots.txt isn't respected unless set by the options.
+func New(startURL string, options ...Option) (*Crawler, error) {
+	parsedURL, err := url.Parse(startURL)
+	if err != nil {
+		return nil, err
+	}
+	if parsedURL.Scheme == "" || parsedURL.Host == "" {
+		return nil, fmt.Errorf("invalid start url: %s", startURL)
+	}
+
+	c := &Crawler{
+		config: queue.ConfigType{
+			QueueLen:   DefaultConcurrency,
+			BulkSize:   DefaultBulkSize,
+			Delay:      DefaultDelay,
+			ReqTimeout: DefaultTimeout,
+			BotName:    DefaultBotName,
+		},
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	if c.config.QueueLen <= 0 {
+		return nil, fmt.Errorf("invalid concurrency: %d", c.config.QueueLen)
+	}
+
+	if c.robots != nil {
+		crawlDelay, err := c.robots.CrawlDelay("*")
+		if err != nil {
+			return nil, err
+		}
+
+		if crawlDelay != nil {
+			c.config.Delay = time.Duration(*crawlDelay) * time.Second
+		}
+	}
+
+	q, err := queue.New(c.config, startURL, c.reporter, c.logger, c.robots)
+	if err != nil {
+		return nil, err
+	}
+
+	q.State = c.state
+	q.Hooks = c.hooks
+	q.Use(c.middlewares...)
+	c.queue = q
+
+	return c, nil
+}
+
+// Run crawls the site and blocks until the crawl is completed or the context is done.
+// The crawled pages are saved to the reporter before it returns.
+func (c *Crawler) Run(ctx context.Context) error {
+	return c.queue.Start(ctx)
+}
+
+// Results returns the channel of the pages sent as they're crawled. The crawler waits for the pages
+// to be received, so the channel should be read until it's closed when Run returns. It must be called before Run.
+func (c *Crawler) Results() <-chan parser.PageData {
+	return c.queue.Results()
+}
+
+// Stream runs the crawler and returns the iterator of the pages as they're crawled.
+// The error which stopped the crawl, if any, is yielded last. Breaking the loop stops the crawl.
+//
+//	for page, err := range c.Stream(ctx) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(page.URL)
+//	}
+func (c *Crawler) Stream(ctx context.Context) iter.Seq2[parser.PageData, error] {
+	return c.queue.Stream(ctx)
+}
+
+// Pages returns the data of the crawled pages.
+func (c *Crawler) Pages() parser.PagesData {
+	return c.queue.Pages()
+}
+
+// Stats returns the statistics of the crawl. It's safe to call it during the crawl.
+func (c *Crawler) Stats() Stats {
+	return c.queue.Stats()
+}
+
+// WithConcurrency sets the number of the parallel requests.
+func WithConcurrency(n int) Option {
+	return func(c *Crawler) {
+		c.config.QueueLen = n
+	}
+}
+
+// WithDepth sets the maximum depth of the crawl, 0 is infinite.
+func WithDepth(depth int) Option {
+	return func(c *Crawler) {
+		c.config.Depth = depth
+	}
+}
+
+// WithLimit sets the maximum number of the crawled pages, 0 is unlimited.
+func WithLimit(limit int) Option {
+	return func(c *Crawler) {
+		c.config.LimitURLs = limit
+	}
+}
+
+// WithDelay sets the delay between the requests.
+// It's overridden by the crawl-delay of robots.txt set by WithRobots.
+func WithDelay(delay time.Duration) Option {
+	return func(c *Crawler) {
+		c.config.Delay = delay
+	}
+}
+
+// WithTimeout sets the timeout of the requests.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Crawler) {
+		c.config.ReqTimeout = timeout
+	}
+}
+
+// WithReporter sets the reporter to save the crawled pages in bulks of the size.
+func WithReporter(reporter queue.Reporter, bulkSize int) Option {
+	return func(c *Crawler) {
+		c.reporter = reporter
+		c.config.BulkSize = bulkSize
+	}
+}
+
+// WithLogger sets the structured logger of the crawl progress and the errors.
+// The pages are logged at the info level with the url, depth, status, duration and worker attributes,
+// the errors at the error level, the skipped URLs and the other details at the debug level.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Crawler) {
+		c.logger = logger
+	}
+}
+
+// WithRobots sets robots.txt to skip the disallowed URLs and to apply the crawl-delay.
+func WithRobots(robots queue.RobotsData) Option {
+	return func(c *Crawler) {
+		c.robots = robots
+	}
+}
+
+// WithState sets the state of the previous crawl for the incremental recrawl.
+func WithState(state queue.CrawlState) Option {
+	return func(c *Crawler) {
+		c.state = state
+	}
+}
+
+// WithBotName sets the bot name to apply the bot-specific meta robots and X-Robots-Tag directives.
+func WithBotName(name string) Option {
+	return func(c *Crawler) {
+		c.config.BotName = name
+	}
+}
+
+// WithRespectNofollow skips the links with rel="nofollow".
+func WithRespectNofollow() Option {
+	return func(c *Crawler) {
+		c.config.RespectNofollowLinks = true
+	}
+}
+
+// WithJSLinks enables the heuristic discovery of the links in JavaScript.
+func WithJSLinks() Option {
+	return func(c *Crawler) {
+		c.config.DiscoverJSLinks = true
+	}
+}
+
+// WithExtractor sets the extractor of the user-defined fields.
+func WithExtractor(extractor parser.Extractor) Option {
+	return func(c *Crawler) {
+		c.config.Extractor = extractor
+	}
+}
+
+// WithRenderer sets the external renderer of the pages.
+func WithRenderer(renderer render.Renderer) Option {
+	return func(c *Crawler) {
+		c.config.Renderer = renderer
+	}
+}
+
+// WithMiddleware adds the middlewares around fetching and parsing of the pages.
+// The first middleware is the outermost. See the middleware package for the built-in middlewares.
+func WithMiddleware(middlewares ...queue.Middleware) Option {
+	return func(c *Crawler) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+// OnRequest sets the hook called before the request. It can modify the headers of the request,
+// the URL is skipped if the hook returns an error.
+func OnRequest(fn func(URL string, header http.Header) error) Option {
+	return func(c *Crawler) {
+		c.hooks.OnRequest = fn
+	}
+}
+
+// OnResponse sets the hook called when the response is received. The hook must not read the body.
+func OnResponse(fn func(resp *http.Response)) Option {
+	return func(c *Crawler) {
+		c.hooks.OnResponse = fn
+	}
+}
+
+// OnPage sets the hook called when the page is processed.
+func OnPage(fn func(page parser.PageData)) Option {
+	return func(c *Crawler) {
+		c.hooks.OnPage = fn
+	}
+}
+
+// OnLink sets the hook called for the links before they're enqueued.
+// The link isn't enqueued if the hook returns false.
+func OnLink(fn func(from string, to string) bool) Option {
+	return func(c *Crawler) {
+		c.hooks.OnLink = fn
+	}
+}
+
+// OnError sets the hook called on the request and parse errors of the pages.
+func OnError(fn func(URL string, err error)) Option {
+	return func(c *Crawler) {
+		c.hooks.OnError = fn
+	}
+}
+
+// OnSkip sets the hook called when the URL is skipped with the reason, e.g. queue.SkipRobots.
+func OnSkip(fn func(URL string, reason string)) Option {
+	return func(c *Crawler) {
+		c.hooks.OnSkip = fn
+	}
+}
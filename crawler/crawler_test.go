@@ -0,0 +1,467 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/demyanovs/urlcrawler/middleware"
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/demyanovs/urlcrawler/queue"
+	"github.com/demyanovs/urlcrawler/state"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			_, _ = w.Write([]byte(`<html><head><title>Home</title></head><body>
+				<a href="/a">A</a><a href="/b">B</a><a href="/secret">Secret</a></body></html>`))
+		case "/a":
+			w.Header().Set("X-Crawler", r.Header.Get("X-Crawler"))
+			_, _ = w.Write([]byte(`<html><head><title>A</title></head><body><a href="/c">C</a></body></html>`))
+		case "/b":
+			_, _ = w.Write([]byte(`<html><head><title>B</title></head></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestRun_Success(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	var mu sync.Mutex
+	var pages, responses, errs []string
+	skipped := map[string]string{}
+
+	c, err := New(server.URL+"/",
+		WithDelay(0),
+		WithConcurrency(2),
+		WithDepth(2),
+		OnRequest(func(URL string, header http.Header) error {
+			header.Set("X-Crawler", "test")
+			return nil
+		}),
+		OnResponse(func(resp *http.Response) {
+			mu.Lock()
+			defer mu.Unlock()
+			responses = append(responses, resp.Status)
+			if resp.Request.URL.Path == "/a" {
+				require.Equal(t, "test", resp.Header.Get("X-Crawler"))
+			}
+		}),
+		OnPage(func(page parser.PageData) {
+			mu.Lock()
+			defer mu.Unlock()
+			pages = append(pages, page.Title)
+		}),
+		OnLink(func(from string, to string) bool {
+			return to != server.URL+"/secret"
+		}),
+		OnError(func(URL string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, URL)
+		}),
+		OnSkip(func(URL string, reason string) {
+			mu.Lock()
+			defer mu.Unlock()
+			skipped[URL] = reason
+		}),
+	)
+	require.NoError(t, err)
+
+	err = c.Run(context.Background())
+	require.NoError(t, err)
+
+	sort.Strings(pages)
+	require.Equal(t, []string{"", "A", "B", "Home"}, pages)
+	require.Len(t, responses, 4)
+	require.Equal(t, []string{server.URL + "/c"}, errs)
+	require.Equal(t, map[string]string{server.URL + "/secret": queue.SkipLinkHook}, skipped)
+	require.Len(t, c.Pages(), 4)
+
+	stats := c.Stats()
+	require.Equal(t, 4, stats.Done)
+	require.Equal(t, 0, stats.ToDo)
+	require.Equal(t, 1, stats.Errors)
+	require.Equal(t, 1, stats.Skipped)
+	require.Positive(t, stats.Elapsed)
+}
+
+func TestRun_RequestHookSkipSuccess(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	c, err := New(server.URL+"/",
+		WithDelay(0),
+		WithDepth(1),
+		OnRequest(func(URL string, header http.Header) error {
+			if URL != server.URL+"/" {
+				return errors.New("skip")
+			}
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+
+	err = c.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, c.Pages(), 1)
+	require.Equal(t, 3, c.Stats().Skipped)
+}
+
+type failingReporter struct{}
+
+func (failingReporter) SaveBulk([]parser.PageData) error {
+	return errors.New("disk is full")
+}
+
+func (failingReporter) Close() error {
+	return nil
+}
+
+func TestRun_ReporterError(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	c, err := New(server.URL+"/", WithDelay(0), WithReporter(failingReporter{}, 1))
+	require.NoError(t, err)
+
+	err = c.Run(context.Background())
+	require.EqualError(t, err, "disk is full")
+}
+
+func TestRun_CanceledError(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	c, err := New(server.URL + "/")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = c.Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Empty(t, c.Pages())
+}
+
+func TestRun_LoggerSuccess(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	var logs bytes.Buffer
+	c, err := New(server.URL+"/", WithDelay(0), WithDepth(1), WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))))
+	require.NoError(t, err)
+
+	err = c.Run(context.Background())
+	require.NoError(t, err)
+
+	records := make(map[string]map[string]any)
+	for _, line := range bytes.Split(bytes.TrimSpace(logs.Bytes()), []byte("\n")) {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(line, &record))
+		if URL, ok := record["url"].(string); ok {
+			records[URL] = record
+		}
+	}
+
+	page := records[server.URL+"/a"]
+	require.Equal(t, "INFO", page["level"])
+	require.Equal(t, "page crawled", page["msg"])
+	require.Equal(t, float64(1), page["depth"])
+	require.Equal(t, float64(200), page["status"])
+	require.Contains(t, page, "duration")
+	require.Contains(t, page, "worker")
+
+	notFound := records[server.URL+"/secret"]
+	require.Equal(t, "ERROR", notFound["level"])
+	require.Equal(t, "page error", notFound["msg"])
+	require.Equal(t, float64(404), notFound["status"])
+
+	// The skipped URLs are logged at the debug level
+	require.NotContains(t, records, server.URL+"/c")
+}
+
+func TestRun_StateIncompleteSuccess(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	filePath := "crawler_state_test.json"
+	defer os.Remove(filePath)
+
+	crawlState, err := state.Load(filePath)
+	require.NoError(t, err)
+
+	c, err := New(server.URL+"/", WithDelay(0), WithState(crawlState))
+	require.NoError(t, err)
+	require.NoError(t, c.Run(context.Background()))
+	require.NoError(t, crawlState.Save())
+
+	// The second run stops by the limit after the first page
+	crawlState, err = state.Load(filePath)
+	require.NoError(t, err)
+
+	c, err = New(server.URL+"/", WithDelay(0), WithLimit(1), WithConcurrency(1), WithState(crawlState))
+	require.NoError(t, err)
+	require.NoError(t, c.Run(context.Background()))
+	require.Empty(t, crawlState.Changes().Removed)
+	require.NoError(t, crawlState.Save())
+
+	crawlState, err = state.Load(filePath)
+	require.NoError(t, err)
+	for _, path := range []string{"/", "/a", "/b", "/c"} {
+		_, ok := crawlState.Previous(server.URL + path)
+		require.True(t, ok, path)
+	}
+}
+
+func TestRun_SkippedOnceSuccess(t *testing.T) {
+	var fetches atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/doc":
+			fetches.Add(1)
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write([]byte("%PDF-1.4"))
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><body><a href="/a">A</a><a href="/b">B</a><a href="/c">C</a><a href="/doc">Doc</a></body></html>`))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		option  Option
+		fetches int64
+	}{
+		{
+			name: "middleware",
+			option: WithMiddleware(middleware.FilterResponse(func(req *queue.Request, resp *http.Response) bool {
+				return resp.Header.Get("Content-Type") != "application/pdf"
+			})),
+			fetches: 1,
+		},
+		{
+			name: "request hook",
+			option: OnRequest(func(URL string, header http.Header) error {
+				if URL == server.URL+"/doc" {
+					return errors.New("vetoed")
+				}
+				return nil
+			}),
+			fetches: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetches.Store(0)
+			var skipped atomic.Int64
+
+			c, err := New(server.URL+"/", WithDelay(0), tt.option, OnSkip(func(URL string, reason string) {
+				skipped.Add(1)
+			}))
+			require.NoError(t, err)
+			require.NoError(t, c.Run(context.Background()))
+
+			require.Equal(t, tt.fetches, fetches.Load())
+			require.Equal(t, int64(1), skipped.Load())
+			require.Equal(t, 1, c.Stats().Skipped)
+			require.Len(t, c.Pages(), 4)
+		})
+	}
+}
+
+func TestRun_JSScriptsSuccess(t *testing.T) {
+	var scriptFetches atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app.js":
+			scriptFetches.Add(1)
+			w.Header().Set("Content-Type", "application/javascript")
+			_, _ = w.Write([]byte(`const routes = [{path: "/dashboard"}, {path: "/settings"}];`))
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><head><script src="/app.js"></script></head><body></body></html>`))
+		}
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var pages []string
+	c, err := New(server.URL+"/", WithDelay(0), WithJSLinks(), WithLimit(3), OnPage(func(page parser.PageData) {
+		mu.Lock()
+		defer mu.Unlock()
+		pages = append(pages, page.URL)
+	}))
+	require.NoError(t, err)
+	require.NoError(t, c.Run(context.Background()))
+
+	// The script is scanned once for the routes, but it isn't a page and doesn't count towards the limit
+	require.Equal(t, int64(1), scriptFetches.Load())
+	require.Len(t, pages, 3)
+	require.NotContains(t, pages, server.URL+"/app.js")
+
+	for _, p := range c.Pages() {
+		if p.URL != server.URL+"/" {
+			require.Equal(t, parser.SourceJSDiscovered, p.Source)
+			require.Equal(t, server.URL+"/", p.Referrer)
+			require.Equal(t, 1, p.Depth)
+		}
+	}
+}
+
+func TestNew_InvalidURLError(t *testing.T) {
+	_, err := New("example.com")
+	require.EqualError(t, err, "invalid start url: example.com")
+
+	_, err = New("https://example.com", WithConcurrency(0))
+	require.EqualError(t, err, "invalid concurrency: 0")
+}
+
+func TestResults_Success(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	c, err := New(server.URL+"/", WithDelay(0))
+	require.NoError(t, err)
+
+	results := c.Results()
+
+	var URLs []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for page := range results {
+			URLs = append(URLs, page.URL)
+		}
+	}()
+
+	err = c.Run(context.Background())
+	require.NoError(t, err)
+
+	<-done
+	sort.Strings(URLs)
+	require.Equal(t, []string{server.URL + "/", server.URL + "/a", server.URL + "/b", server.URL + "/c", server.URL + "/secret"}, URLs)
+}
+
+func TestStream_Success(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	c, err := New(server.URL+"/", WithDelay(0))
+	require.NoError(t, err)
+
+	var titles []string
+	for page, err := range c.Stream(context.Background()) {
+		require.NoError(t, err)
+		titles = append(titles, page.Title)
+	}
+
+	sort.Strings(titles)
+	require.Equal(t, []string{"", "", "A", "B", "Home"}, titles)
+}
+
+func TestStream_BreakSuccess(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	c, err := New(server.URL+"/", WithDelay(0), WithConcurrency(1))
+	require.NoError(t, err)
+
+	var URLs []string
+	for page := range c.Stream(context.Background()) {
+		URLs = append(URLs, page.URL)
+		break
+	}
+
+	require.Equal(t, []string{server.URL + "/"}, URLs)
+	require.Less(t, c.Stats().Done, 5)
+}
+
+func TestStream_ReporterError(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	c, err := New(server.URL+"/", WithDelay(0), WithReporter(failingReporter{}, 1))
+	require.NoError(t, err)
+
+	var errs []error
+	for _, err := range c.Stream(context.Background()) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	require.Len(t, errs, 1)
+	require.EqualError(t, errs[0], "disk is full")
+}
+
+func TestRun_MiddlewareSuccess(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	var skipped []string
+	var depths sync.Map
+	c, err := New(server.URL+"/",
+		WithDelay(0),
+		WithConcurrency(1),
+		WithMiddleware(
+			func(next queue.Handler) queue.Handler {
+				return depthRecorder{Handler: next, depths: &depths}
+			},
+			middleware.Header(http.Header{"X-Crawler": {"test"}}),
+			middleware.FilterResponse(func(_ *queue.Request, resp *http.Response) bool {
+				return resp.StatusCode == http.StatusOK
+			}),
+		),
+		OnResponse(func(resp *http.Response) {
+			if resp.Request.URL.Path == "/a" {
+				require.Equal(t, "test", resp.Header.Get("X-Crawler"))
+			}
+		}),
+		OnSkip(func(URL string, reason string) {
+			require.Equal(t, queue.SkipMiddleware, reason)
+			skipped = append(skipped, URL)
+		}),
+	)
+	require.NoError(t, err)
+
+	err = c.Run(context.Background())
+	require.NoError(t, err)
+
+	sort.Strings(skipped)
+	require.Equal(t, []string{server.URL + "/c", server.URL + "/secret"}, skipped)
+	require.Len(t, c.Pages(), 3)
+
+	depth, _ := depths.Load(server.URL + "/c")
+	require.Equal(t, 2, depth)
+}
+
+// depthRecorder records the depth of the fetched URLs.
+type depthRecorder struct {
+	queue.Handler
+	depths *sync.Map
+}
+
+func (h depthRecorder) Fetch(ctx context.Context, req *queue.Request) (*http.Response, parser.Timing, error) {
+	h.depths.Store(req.URL, req.Depth)
+
+	return h.Handler.Fetch(ctx, req)
+}
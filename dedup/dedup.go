@@ -0,0 +1,154 @@
+package dedup
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/demyanovs/urlcrawler/parser"
+)
+
+// Duplicate types.
+const (
+	TypeExact = "exact"
+	TypeNear  = "near"
+)
+
+// Cluster represents a group of pages with the same or similar content.
+// Similarity is the lowest similarity between the linked pages of the cluster.
+type Cluster struct {
+	Type       string   `json:"type"`
+	Similarity float64  `json:"similarity"`
+	URLs       []string `json:"urls"`
+}
+
+// Find finds exact duplicates by the content hash and near-duplicate clusters
+// with the SimHash similarity equal or above the threshold.
+// Exact duplicates are represented by a single page in near-duplicate clusters.
+func Find(pages parser.PagesData, threshold float64) []Cluster {
+	byHash := make(map[string][]string)
+	simHashes := make(map[string]uint64)
+	var hashes []string
+
+	for _, p := range pages {
+		if p.StatusCode != http.StatusOK || p.ContentHash == "" {
+			continue
+		}
+
+		if _, ok := byHash[p.ContentHash]; !ok {
+			hashes = append(hashes, p.ContentHash)
+			simHashes[p.ContentHash] = p.SimHash
+		}
+		byHash[p.ContentHash] = append(byHash[p.ContentHash], p.URL)
+	}
+
+	sort.Strings(hashes)
+
+	var clusters []Cluster
+	for _, hash := range hashes {
+		if len(byHash[hash]) > 1 {
+			clusters = append(clusters, Cluster{
+				Type:       TypeExact,
+				Similarity: 1,
+				URLs:       sorted(byHash[hash]),
+			})
+		}
+	}
+
+	// Single-linkage clustering of the unique contents
+	parents := make([]int, len(hashes))
+	minSimilarity := make([]float64, len(hashes))
+	for i := range parents {
+		parents[i] = i
+		minSimilarity[i] = 1
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if parents[i] != i {
+			parents[i] = find(parents[i])
+		}
+		return parents[i]
+	}
+
+	for i := 0; i < len(hashes); i++ {
+		for j := i + 1; j < len(hashes); j++ {
+			similarity := parser.Similarity(simHashes[hashes[i]], simHashes[hashes[j]])
+			if similarity < threshold {
+				continue
+			}
+
+			ri, rj := find(i), find(j)
+			if ri != rj {
+				parents[rj] = ri
+			}
+			minSimilarity[ri] = min(minSimilarity[ri], minSimilarity[rj], similarity)
+		}
+	}
+
+	groups := make(map[int][]string)
+	var roots []int
+	for i, hash := range hashes {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], byHash[hash]...)
+	}
+
+	for _, root := range roots {
+		if len(groups[root]) == len(byHash[hashes[root]]) {
+			continue
+		}
+
+		clusters = append(clusters, Cluster{
+			Type:       TypeNear,
+			Similarity: minSimilarity[root],
+			URLs:       sorted(groups[root]),
+		})
+	}
+
+	return clusters
+}
+
+// WriteCSV writes the clusters as CSV with a row per URL.
+func WriteCSV(w io.Writer, clusters []Cluster) error {
+	cw := csv.NewWriter(w)
+
+	data := [][]string{{"Cluster", "Type", "Similarity", "URL"}}
+	for i, c := range clusters {
+		for _, URL := range c.URLs {
+			data = append(data, []string{
+				strconv.Itoa(i + 1),
+				c.Type,
+				strconv.FormatFloat(c.Similarity, 'f', 2, 64),
+				URL,
+			})
+		}
+	}
+
+	return cw.WriteAll(data)
+}
+
+// WriteJSON writes the clusters as JSON.
+func WriteJSON(w io.Writer, clusters []Cluster) error {
+	if clusters == nil {
+		clusters = []Cluster{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(clusters)
+}
+
+func sorted(URLs []string) []string {
+	s := make([]string, len(URLs))
+	copy(s, URLs)
+	sort.Strings(s)
+
+	return s
+}
@@ -0,0 +1,53 @@
+package dedup
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/stretchr/testify/require"
+)
+
+var pages = parser.PagesData{
+	{URL: "https://example.com/a", StatusCode: 200, ContentHash: "a", SimHash: 0xff00ff00ff00ff00},
+	{URL: "https://example.com/a?utm=1", StatusCode: 200, ContentHash: "a", SimHash: 0xff00ff00ff00ff00},
+	{URL: "https://example.com/a?page=2", StatusCode: 200, ContentHash: "a2", SimHash: 0xff00ff00ff00ff01},
+	{URL: "https://example.com/b", StatusCode: 200, ContentHash: "b", SimHash: 0x00ff00ff00ff00ff},
+	{URL: "https://example.com/c", StatusCode: 200, ContentHash: "c", SimHash: 0x0f0f0f0f0f0f0f0f},
+	{URL: "https://example.com/missing", StatusCode: 404},
+}
+
+func TestFind_Success(t *testing.T) {
+	clusters := Find(pages, 0.9)
+
+	require.Equal(t, []Cluster{
+		{
+			Type:       TypeExact,
+			Similarity: 1,
+			URLs:       []string{"https://example.com/a", "https://example.com/a?utm=1"},
+		},
+		{
+			Type:       TypeNear,
+			Similarity: 1 - 1.0/64,
+			URLs:       []string{"https://example.com/a", "https://example.com/a?page=2", "https://example.com/a?utm=1"},
+		},
+	}, clusters)
+}
+
+func TestFind_NoDuplicatesSuccess(t *testing.T) {
+	clusters := Find(pages[3:], 0.9)
+	require.Empty(t, clusters)
+}
+
+func TestWriteCSV_Success(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, Find(pages, 0.9))
+	require.NoError(t, err)
+
+	require.Equal(t, "Cluster,Type,Similarity,URL\n"+
+		"1,exact,1.00,https://example.com/a\n"+
+		"1,exact,1.00,https://example.com/a?utm=1\n"+
+		"2,near,0.98,https://example.com/a\n"+
+		"2,near,0.98,https://example.com/a?page=2\n"+
+		"2,near,0.98,https://example.com/a?utm=1\n", buf.String())
+}
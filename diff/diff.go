@@ -0,0 +1,200 @@
+package diff
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/demyanovs/urlcrawler/parser"
+)
+
+// StatusChange represents a change of the status code of the URL.
+type StatusChange struct {
+	URL       string `json:"url"`
+	OldStatus int    `json:"old_status"`
+	NewStatus int    `json:"new_status"`
+}
+
+// FieldChange represents a change of the page field of the URL.
+type FieldChange struct {
+	URL      string `json:"url"`
+	Field    string `json:"field"`
+	OldValue string `json:"old_value"`
+	NewValue string `json:"new_value"`
+}
+
+// Result represents a difference between two reports.
+type Result struct {
+	Added         []string       `json:"added"`
+	Removed       []string       `json:"removed"`
+	StatusChanges []StatusChange `json:"status_changes"`
+	FieldChanges  []FieldChange  `json:"field_changes"`
+	Regressions   []string       `json:"regressions"`
+}
+
+// Compare compares the old and the new reports.
+func Compare(oldPages, newPages parser.PagesData) Result {
+	oldByURL := byURL(oldPages)
+	newByURL := byURL(newPages)
+
+	result := Result{}
+	for _, URL := range sortedKeys(newByURL) {
+		newPage := newByURL[URL]
+		oldPage, ok := oldByURL[URL]
+		if !ok {
+			result.Added = append(result.Added, URL)
+			if isError(newPage.StatusCode) {
+				result.Regressions = append(result.Regressions, URL)
+			}
+			continue
+		}
+
+		if oldPage.StatusCode != newPage.StatusCode {
+			result.StatusChanges = append(result.StatusChanges, StatusChange{
+				URL:       URL,
+				OldStatus: oldPage.StatusCode,
+				NewStatus: newPage.StatusCode,
+			})
+			if isError(newPage.StatusCode) && !isError(oldPage.StatusCode) {
+				result.Regressions = append(result.Regressions, URL)
+			}
+		}
+
+		result.FieldChanges = append(result.FieldChanges, fieldChanges(oldPage, newPage)...)
+	}
+
+	for _, URL := range sortedKeys(oldByURL) {
+		if _, ok := newByURL[URL]; !ok {
+			result.Removed = append(result.Removed, URL)
+		}
+	}
+
+	return result
+}
+
+// HasRegressions reports whether new 4xx/5xx status codes appeared.
+func (r Result) HasRegressions() bool {
+	return len(r.Regressions) > 0
+}
+
+// WriteText writes the result in a human-readable form.
+func WriteText(w io.Writer, r Result) error {
+	var lines []string
+	for _, URL := range r.Added {
+		lines = append(lines, fmt.Sprintf("+ %s", URL))
+	}
+	for _, URL := range r.Removed {
+		lines = append(lines, fmt.Sprintf("- %s", URL))
+	}
+	for _, c := range r.StatusChanges {
+		lines = append(lines, fmt.Sprintf("~ %s status: %d -> %d", c.URL, c.OldStatus, c.NewStatus))
+	}
+	for _, c := range r.FieldChanges {
+		lines = append(lines, fmt.Sprintf("~ %s %s: %q -> %q", c.URL, c.Field, c.OldValue, c.NewValue))
+	}
+	for _, URL := range r.Regressions {
+		lines = append(lines, fmt.Sprintf("! %s regression", URL))
+	}
+
+	lines = append(lines, fmt.Sprintf(
+		"added: %d, removed: %d, status changes: %d, field changes: %d, regressions: %d",
+		len(r.Added),
+		len(r.Removed),
+		len(r.StatusChanges),
+		len(r.FieldChanges),
+		len(r.Regressions),
+	))
+
+	for _, line := range lines {
+		_, err := fmt.Fprintln(w, line)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteCSV writes the result as CSV with a row per change.
+func WriteCSV(w io.Writer, r Result) error {
+	cw := csv.NewWriter(w)
+
+	data := [][]string{{"Change", "URL", "Field", "OldValue", "NewValue"}}
+	for _, URL := range r.Added {
+		data = append(data, []string{"added", URL, "", "", ""})
+	}
+	for _, URL := range r.Removed {
+		data = append(data, []string{"removed", URL, "", "", ""})
+	}
+	for _, c := range r.StatusChanges {
+		data = append(data, []string{"status", c.URL, "StatusCode", strconv.Itoa(c.OldStatus), strconv.Itoa(c.NewStatus)})
+	}
+	for _, c := range r.FieldChanges {
+		data = append(data, []string{"field", c.URL, c.Field, c.OldValue, c.NewValue})
+	}
+	for _, URL := range r.Regressions {
+		data = append(data, []string{"regression", URL, "", "", ""})
+	}
+
+	return cw.WriteAll(data)
+}
+
+// WriteJSON writes the result as JSON.
+func WriteJSON(w io.Writer, r Result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(r)
+}
+
+func fieldChanges(oldPage, newPage parser.PageData) []FieldChange {
+	fields := []struct {
+		name     string
+		oldValue string
+		newValue string
+	}{
+		{"Title", oldPage.Title, newPage.Title},
+		{"Description", oldPage.Desc, newPage.Desc},
+		{"Keywords", oldPage.Keywords, newPage.Keywords},
+	}
+
+	var changes []FieldChange
+	for _, f := range fields {
+		if f.oldValue != f.newValue {
+			changes = append(changes, FieldChange{
+				URL:      newPage.URL,
+				Field:    f.name,
+				OldValue: f.oldValue,
+				NewValue: f.newValue,
+			})
+		}
+	}
+
+	return changes
+}
+
+func byURL(pages parser.PagesData) map[string]parser.PageData {
+	m := make(map[string]parser.PageData, len(pages))
+	for _, p := range pages {
+		m[p.URL] = p
+	}
+
+	return m
+}
+
+func sortedKeys(m map[string]parser.PageData) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func isError(statusCode int) bool {
+	return statusCode >= 400
+}
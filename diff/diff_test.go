@@ -0,0 +1,61 @@
+package diff
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/stretchr/testify/require"
+)
+
+var oldPages = parser.PagesData{
+	{URL: "https://example.com/", StatusCode: 200, Title: "Home"},
+	{URL: "https://example.com/about", StatusCode: 200, Title: "About"},
+	{URL: "https://example.com/contacts", StatusCode: 200, Title: "Contacts"},
+	{URL: "https://example.com/old", StatusCode: 200, Title: "Old"},
+}
+
+var newPages = parser.PagesData{
+	{URL: "https://example.com/", StatusCode: 200, Title: "Home"},
+	{URL: "https://example.com/about", StatusCode: 200, Title: "About us"},
+	{URL: "https://example.com/contacts", StatusCode: 500, Title: "Contacts"},
+	{URL: "https://example.com/new", StatusCode: 404},
+}
+
+func TestCompare_Success(t *testing.T) {
+	result := Compare(oldPages, newPages)
+
+	require.Equal(t, Result{
+		Added:   []string{"https://example.com/new"},
+		Removed: []string{"https://example.com/old"},
+		StatusChanges: []StatusChange{
+			{URL: "https://example.com/contacts", OldStatus: 200, NewStatus: 500},
+		},
+		FieldChanges: []FieldChange{
+			{URL: "https://example.com/about", Field: "Title", OldValue: "About", NewValue: "About us"},
+		},
+		Regressions: []string{"https://example.com/contacts", "https://example.com/new"},
+	}, result)
+	require.True(t, result.HasRegressions())
+}
+
+func TestCompare_NoChangesSuccess(t *testing.T) {
+	result := Compare(oldPages, oldPages)
+
+	require.Equal(t, Result{}, result)
+	require.False(t, result.HasRegressions())
+}
+
+func TestWriteCSV_Success(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, Compare(oldPages, newPages))
+	require.NoError(t, err)
+
+	require.Equal(t, "Change,URL,Field,OldValue,NewValue\n"+
+		"added,https://example.com/new,,,\n"+
+		"removed,https://example.com/old,,,\n"+
+		"status,https://example.com/contacts,StatusCode,200,500\n"+
+		"field,https://example.com/about,Title,About,About us\n"+
+		"regression,https://example.com/contacts,,,\n"+
+		"regression,https://example.com/new,,,\n", buf.String())
+}
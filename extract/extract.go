@@ -0,0 +1,263 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+)
+
+// Rule represents a rule to extract a field from the page.
+// Exactly one of CSS, XPath and Regex is used to find the values.
+// Attr selects the attribute of the found elements instead of their text.
+// Regex values are the first capturing group if any, otherwise the whole match.
+// Only the first value is extracted unless Multiple is set.
+type Rule struct {
+	Name     string `json:"name"`
+	CSS      string `json:"css,omitempty"`
+	XPath    string `json:"xpath,omitempty"`
+	Regex    string `json:"regex,omitempty"`
+	Attr     string `json:"attr,omitempty"`
+	Multiple bool   `json:"multiple,omitempty"`
+
+	css   cascadia.Sel
+	xpath *sync.Pool
+	regex *regexp.Regexp
+}
+
+// RuleSet represents the rules applied to the pages which URL matches the URLPattern regex.
+// The rules are applied to all the pages if URLPattern is empty.
+type RuleSet struct {
+	URLPattern string `json:"url_pattern,omitempty"`
+	Rules      []Rule `json:"rules"`
+
+	urlPattern *regexp.Regexp
+}
+
+// Extractor extracts the fields from the pages by the rule sets.
+type Extractor struct {
+	ruleSets []RuleSet
+}
+
+// New creates a new Extractor with the given rule sets.
+func New(ruleSets []RuleSet) (*Extractor, error) {
+	for i := range ruleSets {
+		err := ruleSets[i].compile()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Extractor{
+		ruleSets: ruleSets,
+	}, nil
+}
+
+// Load creates a new Extractor with the rule sets from the JSON file.
+func Load(filePath string) (*Extractor, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var ruleSets []RuleSet
+	err = json.Unmarshal(content, &ruleSets)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rules file %s: %s", filePath, err)
+	}
+
+	return New(ruleSets)
+}
+
+// Names returns the names of the fields in the order of the rules.
+func (e *Extractor) Names() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, rs := range e.ruleSets {
+		for _, r := range rs.Rules {
+			if !seen[r.Name] {
+				seen[r.Name] = true
+				names = append(names, r.Name)
+			}
+		}
+	}
+
+	return names
+}
+
+// Extract returns the values of the fields found on the page.
+// The rules of all the rule sets matching the URL are applied.
+func (e *Extractor) Extract(URL string, content []byte) map[string][]string {
+	var doc *html.Node
+	fields := make(map[string][]string)
+
+	for _, rs := range e.ruleSets {
+		if rs.urlPattern != nil && !rs.urlPattern.MatchString(URL) {
+			continue
+		}
+
+		for _, r := range rs.Rules {
+			if !r.Multiple && len(fields[r.Name]) > 0 {
+				continue
+			}
+
+			// Parse the page once and only if it's needed
+			if doc == nil && r.regex == nil {
+				var err error
+				doc, err = html.Parse(bytes.NewReader(content))
+				if err != nil {
+					continue
+				}
+			}
+
+			values := r.values(doc, content)
+			if !r.Multiple && len(values) > 1 {
+				values = values[:1]
+			}
+
+			fields[r.Name] = append(fields[r.Name], values...)
+		}
+	}
+
+	for name, values := range fields {
+		if len(values) == 0 {
+			delete(fields, name)
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return fields
+}
+
+func (rs *RuleSet) compile() error {
+	var err error
+	if rs.URLPattern != "" {
+		rs.urlPattern, err = regexp.Compile(rs.URLPattern)
+		if err != nil {
+			return fmt.Errorf("invalid url pattern %s: %s", rs.URLPattern, err)
+		}
+	}
+
+	for i := range rs.Rules {
+		err = rs.Rules[i].compile()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Rule) compile() error {
+	if r.Name == "" {
+		return fmt.Errorf("rule name is required")
+	}
+
+	var err error
+	var selectors int
+	if r.CSS != "" {
+		selectors++
+		r.css, err = cascadia.Parse(r.CSS)
+		if err != nil {
+			return fmt.Errorf("invalid css selector of %s: %s", r.Name, err)
+		}
+	}
+
+	if r.XPath != "" {
+		selectors++
+		expr, err := xpath.Compile(r.XPath)
+		if err != nil {
+			return fmt.Errorf("invalid xpath of %s: %s", r.Name, err)
+		}
+
+		// The evaluation changes the state of the expression, so every worker gets its own one
+		source := r.XPath
+		r.xpath = &sync.Pool{New: func() any {
+			return xpath.MustCompile(source)
+		}}
+		r.xpath.Put(expr)
+	}
+
+	if r.Regex != "" {
+		selectors++
+		r.regex, err = regexp.Compile(r.Regex)
+		if err != nil {
+			return fmt.Errorf("invalid regex of %s: %s", r.Name, err)
+		}
+	}
+
+	if selectors != 1 {
+		return fmt.Errorf("rule %s must have exactly one of css, xpath and regex", r.Name)
+	}
+
+	return nil
+}
+
+func (r *Rule) values(doc *html.Node, content []byte) []string {
+	var values []string
+	add := func(value string) {
+		value = strings.Join(strings.Fields(value), " ")
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	switch {
+	case r.css != nil:
+		for _, n := range cascadia.QueryAll(doc, r.css) {
+			add(r.nodeValue(n))
+		}
+	case r.xpath != nil:
+		expr := r.xpath.Get().(*xpath.Expr)
+		defer r.xpath.Put(expr)
+
+		switch v := expr.Evaluate(htmlquery.CreateXPathNavigator(doc)).(type) {
+		case *xpath.NodeIterator:
+			for v.MoveNext() {
+				nav := v.Current().(*htmlquery.NodeNavigator)
+				if nav.NodeType() == xpath.ElementNode {
+					add(r.nodeValue(nav.Current()))
+				} else {
+					// Attributes and text nodes selected by the expression
+					add(nav.Value())
+				}
+			}
+		case string:
+			add(v)
+		case float64:
+			add(strconv.FormatFloat(v, 'f', -1, 64))
+		case bool:
+			add(strconv.FormatBool(v))
+		}
+	case r.regex != nil:
+		for _, m := range r.regex.FindAllSubmatch(content, -1) {
+			if len(m) > 1 {
+				add(string(m[1]))
+			} else {
+				add(string(m[0]))
+			}
+		}
+	}
+
+	return values
+}
+
+func (r *Rule) nodeValue(n *html.Node) string {
+	if r.Attr != "" {
+		return htmlquery.SelectAttr(n, r.Attr)
+	}
+
+	return htmlquery.InnerText(n)
+}
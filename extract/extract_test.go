@@ -0,0 +1,124 @@
+package extract
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var page = []byte(`<html><head><title>Product</title></head><body>
+<h1 class="name"> Blue   chair </h1>
+<span class="price" data-currency="EUR">120</span>
+<img class="gallery" src="/a.jpg"><img class="gallery" src="/b.jpg">
+<a href="tel:+123456">Call us</a>
+</body></html>`)
+
+func TestExtract_Success(t *testing.T) {
+	e, err := New([]RuleSet{
+		{
+			Rules: []Rule{
+				{Name: "name", CSS: "h1.name"},
+				{Name: "currency", CSS: ".price", Attr: "data-currency"},
+				{Name: "images", XPath: "//img[@class='gallery']/@src", Multiple: true},
+				{Name: "first_image", CSS: "img.gallery", Attr: "src"},
+				{Name: "images_count", XPath: "count(//img)"},
+				{Name: "phone", Regex: `tel:([0-9+]+)`},
+				{Name: "missing", CSS: ".missing"},
+			},
+		},
+		{
+			URLPattern: `/products/`,
+			Rules: []Rule{
+				{Name: "price", XPath: "//span[@class='price']"},
+			},
+		},
+		{
+			URLPattern: `/blog/`,
+			Rules: []Rule{
+				{Name: "author", CSS: ".author"},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"name", "currency", "images", "first_image", "images_count", "phone", "missing", "price", "author"}, e.Names())
+	require.Equal(t, map[string][]string{
+		"name":         {"Blue chair"},
+		"currency":     {"EUR"},
+		"images":       {"/a.jpg", "/b.jpg"},
+		"first_image":  {"/a.jpg"},
+		"images_count": {"2"},
+		"phone":        {"+123456"},
+		"price":        {"120"},
+	}, e.Extract("https://example.com/products/chair", page))
+}
+
+func TestExtract_ConcurrentSuccess(t *testing.T) {
+	e, err := New([]RuleSet{
+		{
+			Rules: []Rule{
+				{Name: "images_count", XPath: "count(//img)"},
+				{Name: "images", XPath: "//img/@src", Multiple: true},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	// The workers extract the fields in parallel with the same rules
+	var wg sync.WaitGroup
+	results := make([]map[string][]string, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				results[i] = e.Extract("https://example.com/", page)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, fields := range results {
+		require.Equal(t, map[string][]string{
+			"images_count": {"2"},
+			"images":       {"/a.jpg", "/b.jpg"},
+		}, fields)
+	}
+}
+
+func TestExtract_NothingFoundSuccess(t *testing.T) {
+	e, err := New([]RuleSet{{Rules: []Rule{{Name: "author", CSS: ".author"}}}})
+	require.NoError(t, err)
+	require.Nil(t, e.Extract("https://example.com/", page))
+}
+
+func TestNew_InvalidRulesError(t *testing.T) {
+	for _, rs := range []RuleSet{
+		{Rules: []Rule{{CSS: "h1"}}},
+		{Rules: []Rule{{Name: "title"}}},
+		{Rules: []Rule{{Name: "title", CSS: "h1", XPath: "//h1"}}},
+		{Rules: []Rule{{Name: "title", CSS: "h1["}}},
+		{Rules: []Rule{{Name: "title", XPath: "//h1["}}},
+		{Rules: []Rule{{Name: "title", Regex: "(h1"}}},
+		{URLPattern: "(blog", Rules: []Rule{{Name: "title", CSS: "h1"}}},
+	} {
+		_, err := New([]RuleSet{rs})
+		require.Error(t, err)
+	}
+}
+
+func TestLoad_Success(t *testing.T) {
+	filePath := "rules_test.json"
+	err := os.WriteFile(filePath, []byte(`[
+		{"url_pattern": "/products/", "rules": [{"name": "currency", "css": ".price", "attr": "data-currency"}]}
+	]`), 0644)
+	require.NoError(t, err)
+
+	defer os.Remove(filePath)
+
+	e, err := Load(filePath)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{"currency": {"EUR"}}, e.Extract("https://example.com/products/chair", page))
+}
@@ -0,0 +1,215 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/demyanovs/urlcrawler/render"
+)
+
+// Fetcher represents a fetcher of the pages which records timing of the requests.
+// The pages are rendered by Renderer if it's set.
+type Fetcher struct {
+	client   *http.Client
+	Renderer render.Renderer
+}
+
+// New creates a new Fetcher.
+func New() *Fetcher {
+	return &Fetcher{
+		client: &http.Client{},
+	}
+}
+
+// Fetch sends the GET request to the URL and reads the response body.
+// The body of the returned response can be read again.
+func (f *Fetcher) Fetch(ctx context.Context, URL string, header http.Header) (*http.Response, parser.Timing, error) {
+	if f.Renderer != nil {
+		return f.render(ctx, URL, header)
+	}
+
+	var timing parser.Timing
+	var mu sync.Mutex
+	var dnsStart, tlsStart, firstByte time.Time
+	var connected bool
+	connectStarts := make(map[string]time.Time)
+
+	// The callbacks can run concurrently, e.g. for the parallel dual-stack dials, and after the response
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			timing.DNS = time.Since(dnsStart)
+		},
+		ConnectStart: func(network string, addr string) {
+			mu.Lock()
+			defer mu.Unlock()
+			connectStarts[network+" "+addr] = time.Now()
+		},
+		ConnectDone: func(network string, addr string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			// Only the first established connection is used
+			if err != nil || connected {
+				return
+			}
+			connected = true
+			timing.Connect = time.Since(connectStarts[network+" "+addr])
+		},
+		TLSHandshakeStart: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			timing.TLS = time.Since(tlsStart)
+		},
+		GotFirstResponseByte: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			firstByte = time.Now()
+		},
+	}
+
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodGet, URL, nil)
+	if err != nil {
+		return nil, timing, err
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	startedAt := time.Now()
+
+	// snapshot returns the copy of the timing with the total time, the late callbacks don't change it
+	snapshot := func() (parser.Timing, time.Time) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		result := timing
+		result.Total = time.Since(startedAt)
+
+		return result, firstByte
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		result, _ := snapshot()
+		return nil, result, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		result, _ := snapshot()
+		return nil, result, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+
+	result, firstByteAt := snapshot()
+	if firstByteAt.IsZero() {
+		firstByteAt = startedAt
+	}
+
+	result.TTFB = firstByteAt.Sub(startedAt)
+	result.Download = result.Total - result.TTFB
+
+	return resp, result, nil
+}
+
+// render renders the page with the renderer and builds the response from the rendered page.
+// Only the total time is recorded.
+func (f *Fetcher) render(ctx context.Context, URL string, header http.Header) (*http.Response, parser.Timing, error) {
+	var timing parser.Timing
+
+	renderReq := render.Request{
+		URL:     URL,
+		Headers: header,
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		renderReq.Timeout = time.Until(deadline).Milliseconds()
+	}
+
+	startedAt := time.Now()
+	rendered, err := f.Renderer.Render(ctx, renderReq)
+	timing.Total = time.Since(startedAt)
+	if err != nil {
+		return nil, timing, fmt.Errorf("render: %s", err)
+	}
+
+	finalURL := rendered.URL
+	if finalURL == "" {
+		finalURL = URL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, finalURL, nil)
+	if err != nil {
+		return nil, timing, err
+	}
+
+	respHeader := http.Header{}
+	for k, v := range rendered.Headers {
+		respHeader[http.CanonicalHeaderKey(k)] = v
+	}
+	if respHeader.Get("Content-Type") == "" {
+		respHeader.Set("Content-Type", "text/html; charset=utf-8")
+	}
+
+	status := rendered.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        respHeader,
+		Body:          io.NopCloser(strings.NewReader(rendered.HTML)),
+		ContentLength: int64(len(rendered.HTML)),
+		Request:       req,
+	}
+
+	return resp, timing, nil
+}
+
+// TLS returns the TLS connection details and the leaf certificate of the server,
+// or nil if the connection isn't encrypted.
+func TLS(state *tls.ConnectionState) *parser.TLS {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+
+	cert := state.PeerCertificates[0]
+
+	return &parser.TLS{
+		Version:   tls.VersionName(state.Version),
+		Issuer:    cert.Issuer.String(),
+		Subject:   cert.Subject.String(),
+		NotBefore: cert.NotBefore,
+		NotAfter:  cert.NotAfter,
+		DNSNames:  cert.DNSNames,
+	}
+}
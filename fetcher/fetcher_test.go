@@ -0,0 +1,123 @@
+package fetcher
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/demyanovs/urlcrawler/render"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetch_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, `"v1"`, r.Header.Get("If-None-Match"))
+		_, _ = w.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	f := New()
+	resp, timing, err := f.Fetch(context.Background(), server.URL, http.Header{"If-None-Match": {`"v1"`}})
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "<html></html>", string(body))
+	require.Equal(t, int64(13), resp.ContentLength)
+
+	require.Greater(t, timing.Total, timing.TTFB)
+	require.Positive(t, timing.Connect)
+}
+
+func TestFetch_ParallelTimingSuccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	// localhost may resolve to both IPv6 and IPv4, the server only listens on IPv4,
+	// so the failed dials must not overwrite the timing of the established connection
+	URL := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+	f := New()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, timing, err := f.Fetch(context.Background(), URL, nil)
+			require.NoError(t, err)
+			require.GreaterOrEqual(t, timing.Total, timing.TTFB+timing.Download)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFetch_Error(t *testing.T) {
+	f := New()
+	_, _, err := f.Fetch(context.Background(), "http://127.0.0.1:0", nil)
+	require.Error(t, err)
+}
+
+func TestFetch_TLSSuccess(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	f := New()
+	f.client = server.Client()
+	resp, timing, err := f.Fetch(context.Background(), server.URL, nil)
+	require.NoError(t, err)
+	require.Positive(t, timing.TLS)
+
+	info := TLS(resp.TLS)
+	require.NotNil(t, info)
+	require.Equal(t, "TLS 1.3", info.Version)
+	require.Equal(t, "O=Acme Co", info.Issuer)
+	require.Contains(t, info.DNSNames, "example.com")
+	require.True(t, info.NotAfter.After(info.NotBefore))
+}
+
+func TestTLS_PlainHTTPSuccess(t *testing.T) {
+	require.Nil(t, TLS(nil))
+}
+
+func TestFetch_RendererSuccess(t *testing.T) {
+	f := New()
+	fake := render.NewFake(map[string]render.Response{
+		"https://example.com/": {
+			URL:  "https://example.com/home",
+			HTML: "<html><body><a href=\"/about\">About</a></body></html>",
+		},
+	})
+	f.Renderer = fake
+
+	resp, timing, err := f.Fetch(context.Background(), "https://example.com/", http.Header{"If-None-Match": {`"v1"`}})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "https://example.com/home", resp.Request.URL.String())
+	require.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+	require.Positive(t, timing.Total)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "<html><body><a href=\"/about\">About</a></body></html>", string(body))
+
+	require.Equal(t, []string{`"v1"`}, fake.Requests[0].Headers["If-None-Match"])
+}
+
+func TestFetch_RendererError(t *testing.T) {
+	f := New()
+	f.Renderer = render.NewFake(map[string]render.Response{
+		"https://example.com/": {Error: "navigation timeout"},
+	})
+
+	_, _, err := f.Fetch(context.Background(), "https://example.com/", nil)
+	require.EqualError(t, err, "render: navigation timeout")
+}
@@ -1,18 +1,40 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"maps"
 	"net/http"
 	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/demyanovs/robotstxt"
 	_ "golang.org/x/lint"
 
+	"github.com/demyanovs/urlcrawler/assets"
+	"github.com/demyanovs/urlcrawler/audit"
+	"github.com/demyanovs/urlcrawler/cache"
+	"github.com/demyanovs/urlcrawler/dedup"
+	"github.com/demyanovs/urlcrawler/extract"
+	"github.com/demyanovs/urlcrawler/fetcher"
+	"github.com/demyanovs/urlcrawler/metrics"
+	"github.com/demyanovs/urlcrawler/mirror"
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/demyanovs/urlcrawler/progress"
 	"github.com/demyanovs/urlcrawler/queue"
+	"github.com/demyanovs/urlcrawler/render"
 	"github.com/demyanovs/urlcrawler/report"
+	"github.com/demyanovs/urlcrawler/state"
+	"github.com/demyanovs/urlcrawler/warc"
 )
 
 const (
@@ -20,15 +42,40 @@ const (
 )
 
 const (
-	outputCSV  = "csv"
-	outputJSON = "json"
+	outputCSV    = "csv"
+	outputJSON   = "json"
+	outputJSONL  = "jsonl"
+	outputSQLite = "sqlite"
 )
 
-var supportedOutputs = []string{outputCSV, outputJSON}
+var supportedOutputs = []string{outputCSV, outputJSON, outputJSONL, outputSQLite}
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+var (
+	supportedLogLevels  = []string{"debug", "info", "warn", "error"}
+	supportedLogFormats = []string{logFormatText, logFormatJSON}
+)
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		runDiff(os.Args[2:])
+		return
+	}
+
+	// The error is logged by run, so the output files are closed and saved before exiting
+	if err := run(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// run crawls the site by the flags and logs the error which stopped it.
+func run() (err error) {
 	startURL := flag.String("u", "", "Start url (required)")
-	output := flag.String("output", outputCSV, "Output format (csv, json)")
+	output := flag.String("output", outputCSV, "Output format (csv, json, jsonl, sqlite)")
 	outputFile := flag.String("output-file", "", "File path to save r")
 	delay := flag.Int("delay", 1000, "Delay between requests in milliseconds")
 	depth := flag.Int("depth", 0, "Depth of the crawl (0 - infinite")
@@ -36,86 +83,396 @@ func main() {
 	reqTimeout := flag.Int("timeout", 5000, "Request timeout in milliseconds")
 	bulkSize := flag.Int("bulk-size", 30, "Bulk size for saving to the file")
 	queueLen := flag.Int("q-len", 50, "Queue length")
-	quietMode := flag.Bool("q", false, "Quiet mode (no logs")
+	quietMode := flag.Bool("q", false, "Quiet mode, only errors are logged (same as -log-level=error)")
+	logLevel := flag.String("log-level", "info", "Log level (debug, info, warn, error)")
+	logFormat := flag.String("log-format", logFormatText, "Log format (text, json)")
+	logFile := flag.String("log-file", "", "File path to write the logs to instead of stderr")
 	ignoreRobotsTXT := flag.Bool("ignore-robots", false, "Ignore crawl-delay and disallowed URLs from robots.txt")
+	fieldNames := flag.String("fields", "", fmt.Sprintf(
+		"Comma-separated report fields in the order of columns (%s). "+
+			"Default for csv: %s, json and jsonl: all the page data",
+		strings.Join(report.FieldNames(), ", "),
+		strings.Join(report.DefaultFields, ","),
+	))
+	auditFile := flag.String("audit-file", "", "File path to save the SEO and HTTPS audit issues (csv, or json by the file extension)")
+	securityFile := flag.String("security-file", "", "File path to save the security headers issues aggregated per host (csv, or json by the file extension)")
+	checkHTTP := flag.Bool("check-http", false, "Check if the HTTPS pages are also reachable over plain HTTP (with -audit-file)")
+	duplicatesFile := flag.String("duplicates-file", "", "File path to save the duplicate content clusters (csv, or json by the file extension)")
+	duplicatesThreshold := flag.Float64("duplicates-threshold", 0.9, "Minimum content similarity from 0 to 1 of near-duplicate pages")
+	botName := flag.String("bot-name", "urlcrawler", "Bot name to apply bot-specific meta robots and X-Robots-Tag directives")
+	discoverJSLinks := flag.Bool("js-links", false, "Discover links in inline and same-origin scripts and data-href/onclick attributes by heuristics")
+	respectNofollow := flag.Bool("respect-nofollow", false, "Do not follow links with rel=\"nofollow\"")
+	stateFile := flag.String("state", "", "File path to load and save the crawl state for incremental recrawl")
+	assetsFile := flag.String("assets-file", "", "File path to check the assets (images, scripts, stylesheets, etc.) and save the broken and oversized ones (csv, or json by the file extension)")
+	maxAssetSize := flag.Int("max-asset-size", 1024, "Maximum size of the asset in KB to report it as oversized (0 - unlimited)")
+	rulesFile := flag.String("rules", "", "File path of the JSON rules to extract the user-defined fields")
+	warcDir := flag.String("warc-dir", "", "Directory to archive the requests and responses in WARC files")
+	warcPrefix := flag.String("warc-prefix", "urlcrawler", "Prefix of the names of the WARC files")
+	warcMaxSize := flag.Int("warc-max-size", 1024, "Maximum size of the WARC file in MB to start a new one (0 - unlimited)")
+	mirrorDir := flag.String("mirror-dir", "", "Directory to save the fetched pages to mirroring the structure of their URLs")
+	mirrorRewriteLinks := flag.Bool("mirror-rewrite-links", false, "Rewrite the links between the mirrored pages to the relative local paths (with -mirror-dir)")
+	renderCmd := flag.String("render-cmd", "", "Command of the external renderer speaking JSON lines over stdin and stdout to render the pages")
+	renderURL := flag.String("render-url", "", "URL of the local HTTP renderer endpoint to render the pages")
+	cacheMode := flag.String("cache", string(cache.ModeOff), fmt.Sprintf("Mode of the on-disk HTTP response cache (%s)", strings.Join(cacheModes(), ", ")))
+	cacheDir := flag.String("cache-dir", ".urlcrawler-cache", "Directory of the on-disk HTTP response cache")
+	cacheIgnoreControl := flag.Bool("cache-ignore-control", false, "Serve the cached responses regardless of Cache-Control and Expires and cache no-store responses in the read-write mode")
+	showProgress := flag.Bool("progress", true, "Show the live progress of the crawl, updated in place in the terminal or as periodic summary lines otherwise")
+	progressInterval := flag.Int("progress-interval", 10, "Interval of the progress summary lines in seconds if the output is not a terminal")
 
 	flag.Parse()
 
+	if *quietMode {
+		*logLevel = "error"
+	}
+
+	var q *queue.Queue
+	var crawlProgress *progress.Progress
+	if *showProgress && *quietMode == false {
+		// The progress is drawn on stderr with the log lines, so they're written above the display
+		crawlProgress = progress.New(os.Stderr, progress.IsTerminal(os.Stderr), func() queue.Stats {
+			return q.Stats()
+		})
+		crawlProgress.Limit = *limitURLs
+		crawlProgress.Interval = time.Duration(*progressInterval) * time.Second
+	}
+
+	logger, logOutput, err := newLogger(*logLevel, *logFormat, *logFile, crawlProgress)
+	if err != nil {
+		slog.Error("can't create logger", "error", err)
+		return err
+	}
+	if logOutput != nil {
+		defer logOutput.Close()
+	}
+	slog.SetDefault(logger)
+
+	defer func() {
+		if err != nil {
+			logger.Error("fatal error", "error", err)
+		}
+	}()
+
 	if *startURL == "" {
-		log.Fatal("url flag is required")
+		return errors.New("url flag is required")
+	}
+
+	if !slices.Contains(supportedOutputs, *output) {
+		return fmt.Errorf("unsupported output format: %s. Supported formats: %v", *output, supportedOutputs)
+	}
+
+	fields, err := report.ParseFields(*fieldNames)
+	if err != nil {
+		return fmt.Errorf("invalid report fields: %w", err)
+	}
+
+	var extractor parser.Extractor
+	if *rulesFile != "" {
+		rules, err := extract.Load(*rulesFile)
+		if err != nil {
+			return fmt.Errorf("can't load extraction rules: %w", err)
+		}
+
+		extractor = rules
+
+		// JSON reports contain all the extracted fields if no fields are selected
+		if len(fields) > 0 || *output == outputCSV {
+			if len(fields) == 0 {
+				fields, _ = report.ParseFields(strings.Join(report.DefaultFields, ","))
+			}
+
+			for _, name := range rules.Names() {
+				fields = append(fields, report.ExtractedField(name))
+			}
+		}
+	}
+
+	if *renderCmd != "" && *renderURL != "" {
+		return errors.New("render-cmd and render-url flags can't be used together")
 	}
 
-	if *output != outputCSV && *output != outputJSON {
-		log.Fatalf("unsupported output format: %s. Supported formats: %v", *output, supportedOutputs)
+	var renderer render.Renderer
+	var processRenderer *render.ProcessRenderer
+	if *renderCmd != "" {
+		args := strings.Fields(*renderCmd)
+		if len(args) == 0 {
+			return errors.New("render-cmd is empty")
+		}
+
+		processRenderer, err = render.NewProcessRenderer(args[0], args[1:]...)
+		if err != nil {
+			return fmt.Errorf("can't start renderer: %w", err)
+		}
+		defer func() {
+			if closeErr := processRenderer.Close(); closeErr != nil {
+				err = errors.Join(err, fmt.Errorf("can't stop renderer: %w", closeErr))
+			}
+		}()
+
+		renderer = processRenderer
+	} else if *renderURL != "" {
+		renderer = render.NewHTTPRenderer(*renderURL)
 	}
 
-	logger := log.New(log.Writer(), "", log.Ldate|log.Ltime)
+	crawlCache, err := cache.New(*cacheDir, cache.Mode(*cacheMode))
+	if err != nil {
+		return fmt.Errorf("can't create cache: %w", err)
+	}
+	crawlCache.IgnoreCacheControl = *cacheIgnoreControl
 
-	r, reportFile := reportByOutput(*output, *outputFile)
+	r, reportFile := reportByOutput(*output, *outputFile, fields)
 
-	q, err := queue.New(
+	q, err = queue.New(
 		queue.ConfigType{
-			QueueLen:   *queueLen,
-			LimitURLs:  *limitURLs,
-			ReqTimeout: time.Duration(*reqTimeout) * time.Millisecond,
-			Delay:      time.Duration(*delay) * time.Millisecond,
-			BulkSize:   *bulkSize,
-			Quiet:      *quietMode,
-			Depth:      *depth,
+			QueueLen:             *queueLen,
+			LimitURLs:            *limitURLs,
+			ReqTimeout:           time.Duration(*reqTimeout) * time.Millisecond,
+			Delay:                time.Duration(*delay) * time.Millisecond,
+			BulkSize:             *bulkSize,
+			Depth:                *depth,
+			BotName:              *botName,
+			RespectNofollowLinks: *respectNofollow,
+			DiscoverJSLinks:      *discoverJSLinks,
+			Extractor:            extractor,
+			Renderer:             renderer,
 		},
 		*startURL,
 		r,
 		logger,
 		nil,
 	)
+	if err != nil {
+		return fmt.Errorf("can't create queue: %w", err)
+	}
 
 	if *ignoreRobotsTXT == true {
-		if *quietMode == false {
-			logger.Println("ignoring robots.txt")
-		}
+		logger.Info("ignoring robots.txt")
 	} else {
-		if *quietMode == false {
-			log.Println("parsing robots.txt")
-		}
-		robots, err := robotsTXTFromURL(*startURL)
+		logger.Info("parsing robots.txt")
+		robots, err := robotsTXTFromURL(*startURL, crawlCache)
 		if err != nil {
-			log.Fatal(err)
+			return fmt.Errorf("can't get robots.txt: %w", err)
 		}
 
 		q.RobotsData = robots
 
 		crawlDelay, err := robots.CrawlDelay("*")
 		if err != nil {
-			log.Fatal(err)
+			return fmt.Errorf("can't get crawl-delay from robots.txt: %w", err)
 		}
 
 		if crawlDelay != nil {
 			q.Config.Delay = time.Duration(*crawlDelay) * time.Second
-			if *quietMode == false {
-				logger.Printf("found crawl-delay in robots.txt: %ds. Ignoring delay from the config\n", *crawlDelay)
+			logger.Info("found crawl-delay in robots.txt, ignoring delay from the config", "crawl_delay", q.Config.Delay)
+		}
+	}
+
+	if crawlCache.Mode != cache.ModeOff {
+		q.Use(crawlCache.Middleware())
+	}
+
+	var warcWriter *warc.Writer
+	if *warcDir != "" {
+		warcWriter, err = warc.NewWriter(*warcDir, *warcPrefix, int64(*warcMaxSize)<<20)
+		if err != nil {
+			return fmt.Errorf("can't create WARC writer: %w", err)
+		}
+		defer func() {
+			if closeErr := warcWriter.Close(); closeErr != nil {
+				err = errors.Join(err, fmt.Errorf("can't close WARC file: %w", closeErr))
+				return
+			}
+
+			logger.Info("WARC files saved", "files", warcWriter.Files())
+		}()
+
+		q.Use(warcWriter.Middleware())
+	}
+
+	var siteMirror *mirror.Mirror
+	if *mirrorDir != "" {
+		siteMirror, err = mirror.New(*mirrorDir)
+		if err != nil {
+			return fmt.Errorf("can't create mirror: %w", err)
+		}
+		defer func() {
+			if closeErr := siteMirror.Close(); closeErr != nil {
+				err = errors.Join(err, fmt.Errorf("can't save mirror: %w", closeErr))
 			}
+		}()
+		siteMirror.RewriteLinks = *mirrorRewriteLinks
+
+		q.Use(siteMirror.Middleware())
+	}
+
+	var crawlState *state.State
+	if *stateFile != "" {
+		crawlState, err = state.Load(*stateFile)
+		if err != nil {
+			return fmt.Errorf("can't load crawl state: %w", err)
 		}
+
+		q.State = crawlState
 	}
 
-	if err != nil {
-		log.Fatal(err)
+	printConfig(q, *output, reportFile, *ignoreRobotsTXT, logger)
+
+	if crawlProgress != nil {
+		q.Hooks.OnPage = crawlProgress.Page
+		crawlProgress.Start()
+	}
+
+	// The state is saved after the crawl even if it failed, the unreached pages are kept in it
+	if crawlState != nil {
+		defer func() {
+			if saveErr := crawlState.Save(); saveErr != nil {
+				err = errors.Join(err, fmt.Errorf("can't save crawl state: %w", saveErr))
+				return
+			}
+
+			printChanges(crawlState.Changes(), logger)
+		}()
+	}
+
+	// Stop crawling and save the crawled pages on interrupt
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err = q.Start(ctx)
+	stop()
+	if crawlProgress != nil {
+		crawlProgress.Stop()
+	}
+	if errors.Is(err, context.Canceled) {
+		logger.Warn("crawling interrupted")
+	} else if err != nil {
+		return fmt.Errorf("crawling failed: %w", err)
+	}
+
+	if logger.Enabled(context.Background(), slog.LevelInfo) {
+		printCertificates(audit.Certificates(q.Pages()), logger)
+		logResponseTimes(metrics.Summarize(q.Pages()), logger)
 	}
 
-	if *quietMode == false {
-		printConfig(q, *output, reportFile, *ignoreRobotsTXT, logger)
+	// Stop the checks after the crawl on interrupt, the interrupted crawl is still checked
+	checkCtx, stopChecks := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stopChecks()
+
+	if *auditFile != "" {
+		pages := q.Pages()
+
+		var httpReachable []string
+		if *checkHTTP {
+			httpReachable = audit.HTTPReachable(checkCtx, pages, audit.HTTPCheckConfig{
+				Concurrency: *queueLen,
+				Timeout:     time.Duration(*reqTimeout) * time.Millisecond,
+				Delay:       q.Config.Delay,
+				Allowed:     robotsAllowed(q.RobotsData, *startURL),
+			})
+			if checkCtx.Err() != nil {
+				logger.Warn("checking HTTP versions of pages interrupted")
+			}
+		}
+
+		issues := append(audit.SEO(pages, audit.DefaultSEOConfig), audit.HTTPS(pages, httpReachable, audit.DefaultHTTPSConfig)...)
+		err = saveFile(*auditFile, func(w io.Writer) error {
+			return audit.WriteCSV(w, issues)
+		}, func(w io.Writer) error {
+			return audit.WriteJSON(w, issues)
+		})
+		if err != nil {
+			return fmt.Errorf("can't save audit issues: %w", err)
+		}
+	}
+
+	if *securityFile != "" {
+		issues := audit.Security(q.Pages())
+		err = saveFile(*securityFile, func(w io.Writer) error {
+			return audit.WriteSecurityCSV(w, issues)
+		}, func(w io.Writer) error {
+			return audit.WriteSecurityJSON(w, issues)
+		})
+		if err != nil {
+			return fmt.Errorf("can't save security issues: %w", err)
+		}
+	}
+
+	if *assetsFile != "" {
+		pages := q.Pages()
+		URLs := assets.URLs(pages)
+		logger.Info("checking assets", "count", len(URLs))
+
+		checker := assets.NewChecker()
+		checker.Concurrency = *queueLen
+		checker.Timeout = time.Duration(*reqTimeout) * time.Millisecond
+		checker.Delay = q.Config.Delay
+		checker.Allowed = robotsAllowed(q.RobotsData, *startURL)
+		results := checker.Check(checkCtx, URLs)
+		if checkCtx.Err() != nil {
+			logger.Warn("checking assets interrupted", "checked", len(results))
+		}
+
+		issues := assets.Issues(pages, results, int64(*maxAssetSize)*1024)
+		err = saveFile(*assetsFile, func(w io.Writer) error {
+			return assets.WriteCSV(w, issues)
+		}, func(w io.Writer) error {
+			return assets.WriteJSON(w, issues)
+		})
+		if err != nil {
+			return fmt.Errorf("can't save assets issues: %w", err)
+		}
+
+		logger.Info("found broken or oversized assets", "count", len(issues))
+	}
+
+	if *duplicatesFile != "" {
+		clusters := dedup.Find(q.Pages(), *duplicatesThreshold)
+		err = saveFile(*duplicatesFile, func(w io.Writer) error {
+			return dedup.WriteCSV(w, clusters)
+		}, func(w io.Writer) error {
+			return dedup.WriteJSON(w, clusters)
+		})
+		if err != nil {
+			return fmt.Errorf("can't save duplicate content clusters: %w", err)
+		}
+
+		logger.Info("found duplicate content clusters", "count", len(clusters))
+	}
+
+	return nil
+}
+
+// robotsAllowed returns the function which reports whether the URL on the host of the start URL
+// is allowed by robots.txt. The URLs on other hosts are allowed. It returns nil if robots.txt is ignored.
+func robotsAllowed(robots queue.RobotsData, startURL string) func(URL string) bool {
+	if robots == nil {
+		return nil
+	}
+
+	start, err := url.Parse(startURL)
+	if err != nil {
+		return nil
 	}
 
-	q.Start()
+	return func(URL string) bool {
+		u, err := url.Parse(URL)
+		if err != nil || u.Host != start.Host {
+			return true
+		}
+
+		return robots.IsAllowed("*", u.RequestURI())
+	}
 }
 
-func robotsTXTFromURL(startURL string) (*robotstxt.RobotsData, error) {
+// robotsTXTFromURL fetches robots.txt of the host of the URL through the cache.
+func robotsTXTFromURL(startURL string, c *cache.Cache) (*robotstxt.RobotsData, error) {
 	parsedURL, err := url.Parse(startURL)
 	if err != nil || parsedURL == nil {
 		return nil, err
 	}
 
 	robotsPath := fmt.Sprintf("%s://%s/robots.txt", parsedURL.Scheme, parsedURL.Host)
-	resp, err := http.Get(robotsPath)
+	req := &queue.Request{URL: robotsPath, Header: http.Header{}}
+	resp, _, err := c.Fetch(context.Background(), req, func(ctx context.Context, req *queue.Request) (*http.Response, parser.Timing, error) {
+		return fetcher.New().Fetch(ctx, req.URL, req.Header)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -129,42 +486,156 @@ func robotsTXTFromURL(startURL string) (*robotstxt.RobotsData, error) {
 	return robots, nil
 }
 
-func reportByOutput(output string, outputFile string) (queue.Reporter, string) {
+// cacheModes returns the names of the supported cache modes.
+func cacheModes() []string {
+	var modes []string
+	for _, mode := range cache.Modes {
+		modes = append(modes, string(mode))
+	}
+
+	return modes
+}
+
+// newLogger creates the logger with the level and the format writing to the file,
+// or to stderr above the progress display if the file isn't set. The file is returned to be closed.
+func newLogger(level string, format string, filePath string, p *progress.Progress) (*slog.Logger, *os.File, error) {
+	if !slices.Contains(supportedLogLevels, level) {
+		return nil, nil, fmt.Errorf("unsupported log level: %s. Supported levels: %v", level, supportedLogLevels)
+	}
+	if !slices.Contains(supportedLogFormats, format) {
+		return nil, nil, fmt.Errorf("unsupported log format: %s. Supported formats: %v", format, supportedLogFormats)
+	}
+
+	var options slog.HandlerOptions
+	var l slog.Level
+	err := l.UnmarshalText([]byte(level))
+	if err != nil {
+		return nil, nil, err
+	}
+	options.Level = l
+
+	var w io.Writer = os.Stderr
+	var file *os.File
+	if filePath != "" {
+		file, err = os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		w = file
+	} else if p != nil {
+		w = p.Writer(w)
+	}
+
+	if format == logFormatJSON {
+		return slog.New(slog.NewJSONHandler(w, &options)), file, nil
+	}
+
+	return slog.New(slog.NewTextHandler(w, &options)), file, nil
+}
+
+func reportByOutput(output string, outputFile string, fields []report.Field) (queue.Reporter, string) {
 	var r queue.Reporter
 	if output == outputJSON {
 		if outputFile == "" {
 			outputFile = fmt.Sprintf("%s.%s", fileNameDefault, outputJSON)
 		}
-		r = report.NewJSONReport(outputFile)
+		r = report.NewJSONReport(outputFile, fields...)
+	} else if output == outputJSONL {
+		if outputFile == "" {
+			outputFile = fmt.Sprintf("%s.%s", fileNameDefault, outputJSONL)
+		}
+		r = report.NewJSONLReport(outputFile, fields...)
+	} else if output == outputSQLite {
+		if outputFile == "" {
+			outputFile = fmt.Sprintf("%s.%s", fileNameDefault, outputSQLite)
+		}
+		r = report.NewSQLiteReport(outputFile)
 	} else {
 		if outputFile == "" {
 			outputFile = fmt.Sprintf("%s.%s", fileNameDefault, outputCSV)
 		}
-		r = report.NewCSVReport(outputFile)
+		r = report.NewCSVReport(outputFile, fields...)
 	}
 
 	return r, outputFile
 }
 
-func printConfig(queue *queue.Queue, output string, outputFile string, ignoreRobotsTXT bool, logger *log.Logger) {
-	logger.Printf(
-		"Starting crawling, "+
-			"delay: %dms, "+
-			"depth: %d, "+
-			"limit: %d, "+
-			"reqTimeout: %dms, "+
-			"bulk-size: %d, "+
-			"output: %s, "+
-			"output-file: %s, "+
-			"ignore-robots: %t "+
-			"\n",
-		queue.Config.Delay/time.Millisecond,
-		queue.Config.Depth,
-		queue.Config.LimitURLs,
-		queue.Config.ReqTimeout/time.Millisecond,
-		queue.Config.BulkSize,
-		output,
-		outputFile,
-		ignoreRobotsTXT,
+func printConfig(queue *queue.Queue, output string, outputFile string, ignoreRobotsTXT bool, logger *slog.Logger) {
+	logger.Info(
+		"starting crawling",
+		"delay", queue.Config.Delay,
+		"depth", queue.Config.Depth,
+		"limit", queue.Config.LimitURLs,
+		"req_timeout", queue.Config.ReqTimeout,
+		"bulk_size", queue.Config.BulkSize,
+		"output", output,
+		"output_file", outputFile,
+		"ignore_robots", ignoreRobotsTXT,
+	)
+}
+
+// saveFile saves the file as JSON if it has the .json extension, otherwise as CSV.
+func saveFile(filePath string, writeCSV func(w io.Writer) error, writeJSON func(w io.Writer) error) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if strings.ToLower(filepath.Ext(filePath)) == ".json" {
+		return writeJSON(file)
+	}
+
+	return writeCSV(file)
+}
+
+func printCertificates(certificates []audit.Certificate, logger *slog.Logger) {
+	for _, c := range certificates {
+		logger.Info(
+			"certificate",
+			"host", c.Host,
+			"version", c.Version,
+			"issuer", c.Issuer,
+			"expires", c.NotAfter.Format(time.DateOnly),
+			"sans", c.DNSNames,
+		)
+	}
+}
+
+// logResponseTimes logs the response time percentiles in total, per depth and per path prefix.
+func logResponseTimes(s metrics.Summary, logger *slog.Logger) {
+	percentiles := func(p metrics.Percentiles) []any {
+		return []any{
+			"count", p.Count,
+			"p50", p.P50.Round(time.Millisecond),
+			"p90", p.P90.Round(time.Millisecond),
+			"p99", p.P99.Round(time.Millisecond),
+		}
+	}
+
+	logger.Info("response time", percentiles(s.Total)...)
+
+	depths := slices.Sorted(maps.Keys(s.ByDepth))
+	for _, depth := range depths {
+		logger.Info("response time", append([]any{"depth", depth}, percentiles(s.ByDepth[depth])...)...)
+	}
+
+	prefixes := slices.Sorted(maps.Keys(s.ByPrefix))
+	for _, prefix := range prefixes {
+		logger.Info("response time", append([]any{"path", prefix}, percentiles(s.ByPrefix[prefix])...)...)
+	}
+}
+
+func printChanges(changes state.Changes, logger *slog.Logger) {
+	logger.Info(
+		"changes since the last run",
+		"new", len(changes.New),
+		"changed", len(changes.Changed),
+		"unchanged", len(changes.Unchanged),
+		"removed", len(changes.Removed),
 	)
+
+	for _, URL := range changes.Removed {
+		logger.Info("removed", "url", URL)
+	}
 }
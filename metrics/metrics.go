@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/demyanovs/urlcrawler/parser"
+)
+
+// Percentiles represents percentiles of the request durations.
+type Percentiles struct {
+	Count int
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+}
+
+// Summary represents a summary of the request durations of the crawl.
+type Summary struct {
+	Total    Percentiles
+	ByDepth  map[int]Percentiles
+	ByPrefix map[string]Percentiles
+}
+
+// Summarize calculates percentiles of the total request durations
+// for all the pages, per depth and per path prefix.
+// Pages which were not fetched are skipped.
+func Summarize(pages parser.PagesData) Summary {
+	var total []time.Duration
+	byDepth := make(map[int][]time.Duration)
+	byPrefix := make(map[string][]time.Duration)
+
+	for _, p := range pages {
+		if p.StatusCode == 0 {
+			continue
+		}
+
+		d := p.Timing.Total
+		total = append(total, d)
+		byDepth[p.Depth] = append(byDepth[p.Depth], d)
+
+		prefix := PathPrefix(p.URL)
+		byPrefix[prefix] = append(byPrefix[prefix], d)
+	}
+
+	summary := Summary{
+		Total:    percentiles(total),
+		ByDepth:  make(map[int]Percentiles, len(byDepth)),
+		ByPrefix: make(map[string]Percentiles, len(byPrefix)),
+	}
+
+	for depth, durations := range byDepth {
+		summary.ByDepth[depth] = percentiles(durations)
+	}
+
+	for prefix, durations := range byPrefix {
+		summary.ByPrefix[prefix] = percentiles(durations)
+	}
+
+	return summary
+}
+
+// PathPrefix returns the first segment of the URL path, e.g. /wiki for /wiki/Moscow.
+func PathPrefix(URL string) string {
+	parsedURL, err := url.Parse(URL)
+	if err != nil {
+		return "/"
+	}
+
+	segment, _, _ := strings.Cut(strings.TrimPrefix(parsedURL.Path, "/"), "/")
+
+	return "/" + segment
+}
+
+func percentiles(durations []time.Duration) Percentiles {
+	if len(durations) == 0 {
+		return Percentiles{}
+	}
+
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	return Percentiles{
+		Count: len(sorted),
+		P50:   percentile(sorted, 50),
+		P90:   percentile(sorted, 90),
+		P99:   percentile(sorted, 99),
+	}
+}
+
+// percentile returns the nearest-rank percentile of the sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
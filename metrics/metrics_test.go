@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize_Success(t *testing.T) {
+	var pages parser.PagesData
+	for i := 1; i <= 100; i++ {
+		pages = append(pages, parser.PageData{
+			URL:        "https://example.com/wiki/page",
+			StatusCode: 200,
+			Depth:      i % 2,
+			Timing:     parser.Timing{Total: time.Duration(i) * time.Millisecond},
+		})
+	}
+	pages = append(pages, parser.PageData{URL: "https://example.com/", StatusCode: 200, Timing: parser.Timing{Total: time.Second}})
+	pages = append(pages, parser.PageData{URL: "https://example.com/failed"})
+
+	summary := Summarize(pages)
+
+	require.Equal(t, Percentiles{Count: 101, P50: 51 * time.Millisecond, P90: 91 * time.Millisecond, P99: 100 * time.Millisecond}, summary.Total)
+	require.Equal(t, Percentiles{Count: 100, P50: 50 * time.Millisecond, P90: 90 * time.Millisecond, P99: 99 * time.Millisecond}, summary.ByPrefix["/wiki"])
+	require.Equal(t, Percentiles{Count: 1, P50: time.Second, P90: time.Second, P99: time.Second}, summary.ByPrefix["/"])
+	require.Equal(t, 51, summary.ByDepth[0].Count)
+	require.Equal(t, 50, summary.ByDepth[1].Count)
+}
+
+func TestPathPrefix_Success(t *testing.T) {
+	require.Equal(t, "/", PathPrefix("https://example.com"))
+	require.Equal(t, "/", PathPrefix("https://example.com/"))
+	require.Equal(t, "/about", PathPrefix("https://example.com/about"))
+	require.Equal(t, "/wiki", PathPrefix("https://example.com/wiki/Moscow?a=1"))
+}
@@ -0,0 +1,170 @@
+// Package middleware provides the middlewares of the queue for the common cross-cutting concerns:
+// header injection, throttling, URL rewriting and response filtering.
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/demyanovs/urlcrawler/queue"
+)
+
+// FetchFunc represents a function fetching the page.
+type FetchFunc func(ctx context.Context, req *queue.Request) (*http.Response, parser.Timing, error)
+
+// ParseFunc represents a function parsing the response.
+type ParseFunc func(ctx context.Context, req *queue.Request, resp *http.Response) (parser.PageData, []string, error)
+
+// fetchHandler represents a handler which overrides fetching of the next handler.
+type fetchHandler struct {
+	queue.Handler
+	fetch FetchFunc
+}
+
+// Fetch fetches the page with the fetch function.
+func (h fetchHandler) Fetch(ctx context.Context, req *queue.Request) (*http.Response, parser.Timing, error) {
+	return h.fetch(ctx, req)
+}
+
+// parseHandler represents a handler which overrides parsing of the next handler.
+type parseHandler struct {
+	queue.Handler
+	parse ParseFunc
+}
+
+// Parse parses the response with the parse function.
+func (h parseHandler) Parse(ctx context.Context, req *queue.Request, resp *http.Response) (parser.PageData, []string, error) {
+	return h.parse(ctx, req, resp)
+}
+
+// Fetch returns the middleware which wraps fetching of the pages.
+// The function gets the fetch function of the next handler.
+func Fetch(fn func(ctx context.Context, req *queue.Request, next FetchFunc) (*http.Response, parser.Timing, error)) queue.Middleware {
+	return func(next queue.Handler) queue.Handler {
+		return fetchHandler{
+			Handler: next,
+			fetch: func(ctx context.Context, req *queue.Request) (*http.Response, parser.Timing, error) {
+				return fn(ctx, req, next.Fetch)
+			},
+		}
+	}
+}
+
+// Parse returns the middleware which wraps parsing of the responses.
+// The function gets the parse function of the next handler.
+func Parse(fn func(ctx context.Context, req *queue.Request, resp *http.Response, next ParseFunc) (parser.PageData, []string, error)) queue.Middleware {
+	return func(next queue.Handler) queue.Handler {
+		return parseHandler{
+			Handler: next,
+			parse: func(ctx context.Context, req *queue.Request, resp *http.Response) (parser.PageData, []string, error) {
+				return fn(ctx, req, resp, next.Parse)
+			},
+		}
+	}
+}
+
+// Header returns the middleware which sets the headers of the requests.
+func Header(header http.Header) queue.Middleware {
+	return Fetch(func(ctx context.Context, req *queue.Request, next FetchFunc) (*http.Response, parser.Timing, error) {
+		for k, v := range header {
+			req.Header[http.CanonicalHeaderKey(k)] = v
+		}
+
+		return next(ctx, req)
+	})
+}
+
+// RewriteURL returns the middleware which rewrites the URLs of the requests,
+// e.g. to fetch the pages from a staging host. The pages are recorded under the original URLs:
+// the URL of the request of the response is restored to the original one, the final URLs of the redirects
+// on the rewritten host are moved to the original host.
+func RewriteURL(rewrite func(URL string) string) queue.Middleware {
+	return Fetch(func(ctx context.Context, req *queue.Request, next FetchFunc) (*http.Response, parser.Timing, error) {
+		originalURL := req.URL
+		rewrittenURL := rewrite(originalURL)
+
+		req.URL = rewrittenURL
+		resp, timing, err := next(ctx, req)
+		req.URL = originalURL
+
+		if resp != nil && resp.Request != nil && resp.Request.URL != nil {
+			resp.Request = resp.Request.Clone(resp.Request.Context())
+			resp.Request.URL = restoreURL(resp.Request.URL, originalURL, rewrittenURL)
+		}
+
+		return resp, timing, err
+	})
+}
+
+// restoreURL returns the original URL if the final URL is the rewritten one,
+// or the final URL moved to the original host if it's on the rewritten host.
+func restoreURL(finalURL *url.URL, originalURL string, rewrittenURL string) *url.URL {
+	original, err := url.Parse(originalURL)
+	if err != nil {
+		return finalURL
+	}
+	if finalURL.String() == rewrittenURL {
+		return original
+	}
+
+	rewritten, err := url.Parse(rewrittenURL)
+	if err != nil || finalURL.Scheme != rewritten.Scheme || finalURL.Host != rewritten.Host {
+		return finalURL
+	}
+
+	restored := *finalURL
+	restored.Scheme = original.Scheme
+	restored.Host = original.Host
+
+	return &restored
+}
+
+// Throttle returns the middleware which sends the requests to the same host not more often than the interval.
+// The waiting time counts towards the request timeout.
+func Throttle(interval time.Duration) queue.Middleware {
+	var mu sync.Mutex
+	nextAt := make(map[string]time.Time)
+
+	return Fetch(func(ctx context.Context, req *queue.Request, next FetchFunc) (*http.Response, parser.Timing, error) {
+		var host string
+		if u, err := url.Parse(req.URL); err == nil {
+			host = u.Host
+		}
+
+		mu.Lock()
+		at := nextAt[host]
+		if now := time.Now(); at.Before(now) {
+			at = now
+		}
+		nextAt[host] = at.Add(interval)
+		mu.Unlock()
+
+		timer := time.NewTimer(time.Until(at))
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return nil, parser.Timing{}, ctx.Err()
+		case <-timer.C:
+		}
+
+		return next(ctx, req)
+	})
+}
+
+// FilterResponse returns the middleware which skips the pages if keep returns false for their responses,
+// e.g. to skip the pages of the content types which aren't needed. The skipped pages aren't parsed and recorded.
+func FilterResponse(keep func(req *queue.Request, resp *http.Response) bool) queue.Middleware {
+	return Parse(func(ctx context.Context, req *queue.Request, resp *http.Response, next ParseFunc) (parser.PageData, []string, error) {
+		if !keep(req, resp) {
+			resp.Body.Close()
+			return parser.PageData{}, nil, queue.ErrSkip
+		}
+
+		return next(ctx, req, resp)
+	})
+}
@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/demyanovs/urlcrawler/crawler"
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/demyanovs/urlcrawler/queue"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHandler records the fetched requests and returns the responses with the content type.
+type fakeHandler struct {
+	contentType string
+	requests    []queue.Request
+}
+
+func (h *fakeHandler) Fetch(_ context.Context, req *queue.Request) (*http.Response, parser.Timing, error) {
+	h.requests = append(h.requests, *req)
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {h.contentType}},
+		Body:       io.NopCloser(strings.NewReader("")),
+	}, parser.Timing{}, nil
+}
+
+func (h *fakeHandler) Parse(_ context.Context, req *queue.Request, _ *http.Response) (parser.PageData, []string, error) {
+	return parser.PageData{URL: req.URL}, []string{"a"}, nil
+}
+
+func TestHeader_Success(t *testing.T) {
+	next := &fakeHandler{}
+	h := Header(http.Header{"authorization": {"Bearer token"}})(next)
+
+	_, _, err := h.Fetch(context.Background(), &queue.Request{URL: "https://example.com/", Header: http.Header{"If-None-Match": {`"v1"`}}})
+	require.NoError(t, err)
+
+	require.Equal(t, http.Header{
+		"Authorization": {"Bearer token"},
+		"If-None-Match": {`"v1"`},
+	}, next.requests[0].Header)
+}
+
+func TestRewriteURL_Success(t *testing.T) {
+	next := &fakeHandler{}
+	h := RewriteURL(func(URL string) string {
+		return strings.Replace(URL, "https://example.com", "http://127.0.0.1:8080", 1)
+	})(next)
+
+	_, _, err := h.Fetch(context.Background(), &queue.Request{URL: "https://example.com/docs", Header: http.Header{}})
+	require.NoError(t, err)
+	require.Equal(t, "http://127.0.0.1:8080/docs", next.requests[0].URL)
+
+	// Parsing isn't wrapped
+	page, links, err := h.Parse(context.Background(), &queue.Request{URL: "https://example.com/docs"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/docs", page.URL)
+	require.Equal(t, []string{"a"}, links)
+}
+
+func TestRewriteURL_CrawlSuccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><body><a href="/a">A</a><a href="/old">Old</a>
+				<script>fetch("https://example.com/api/items")</script></body></html>`))
+		case "/old":
+			http.Redirect(w, r, "/b", http.StatusMovedPermanently)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><body></body></html>`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := crawler.New("https://example.com/",
+		crawler.WithDelay(0),
+		crawler.WithJSLinks(),
+		crawler.WithMiddleware(RewriteURL(func(URL string) string {
+			return strings.Replace(URL, "https://example.com", server.URL, 1)
+		})),
+	)
+	require.NoError(t, err)
+	require.NoError(t, c.Run(context.Background()))
+
+	var URLs []string
+	for _, p := range c.Pages() {
+		require.Empty(t, p.Error)
+		URLs = append(URLs, p.URL)
+	}
+	sort.Strings(URLs)
+
+	// The redirected page is recorded under its final URL on the original host,
+	// the absolute links to the original host are followed
+	require.Equal(t, []string{
+		"https://example.com/",
+		"https://example.com/a",
+		"https://example.com/api/items",
+		"https://example.com/b",
+	}, URLs)
+}
+
+func TestThrottle_Success(t *testing.T) {
+	next := &fakeHandler{}
+	h := Throttle(50 * time.Millisecond)(next)
+
+	startedAt := time.Now()
+	for _, URL := range []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"} {
+		_, _, err := h.Fetch(context.Background(), &queue.Request{URL: URL, Header: http.Header{}})
+		require.NoError(t, err)
+	}
+	require.GreaterOrEqual(t, time.Since(startedAt), 100*time.Millisecond)
+
+	// Other hosts aren't throttled
+	startedAt = time.Now()
+	_, _, err := h.Fetch(context.Background(), &queue.Request{URL: "https://example.org/", Header: http.Header{}})
+	require.NoError(t, err)
+	require.Less(t, time.Since(startedAt), 50*time.Millisecond)
+	require.Len(t, next.requests, 4)
+}
+
+func TestThrottle_CanceledError(t *testing.T) {
+	h := Throttle(time.Hour)(&fakeHandler{})
+
+	_, _, err := h.Fetch(context.Background(), &queue.Request{URL: "https://example.com/a", Header: http.Header{}})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, _, err = h.Fetch(ctx, &queue.Request{URL: "https://example.com/b", Header: http.Header{}})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestFilterResponse_Success(t *testing.T) {
+	htmlOnly := FilterResponse(func(_ *queue.Request, resp *http.Response) bool {
+		return strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html")
+	})
+
+	h := htmlOnly(&fakeHandler{contentType: "text/html"})
+	resp, _, err := h.Fetch(context.Background(), &queue.Request{URL: "https://example.com/", Header: http.Header{}})
+	require.NoError(t, err)
+	page, _, err := h.Parse(context.Background(), &queue.Request{URL: "https://example.com/"}, resp)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/", page.URL)
+
+	h = htmlOnly(&fakeHandler{contentType: "application/pdf"})
+	resp, _, err = h.Fetch(context.Background(), &queue.Request{URL: "https://example.com/doc.pdf", Header: http.Header{}})
+	require.NoError(t, err)
+	_, _, err = h.Parse(context.Background(), &queue.Request{URL: "https://example.com/doc.pdf"}, resp)
+	require.ErrorIs(t, err, queue.ErrSkip)
+}
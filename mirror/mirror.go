@@ -0,0 +1,312 @@
+// Package mirror saves the crawled pages to a directory tree mirroring the structure of their URLs,
+// optionally rewriting the links between them to the relative local paths to browse the site offline.
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/demyanovs/urlcrawler/middleware"
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/demyanovs/urlcrawler/queue"
+)
+
+// maxSegmentLen limits the length of the names of the files and directories.
+const maxSegmentLen = 200
+
+var (
+	regExLinkAttr    = regexp.MustCompile(`(?i)(\s(?:href|src|action)\s*=\s*)(["'])([^"']*)(["'])`)
+	regExUnsafeChars = regexp.MustCompile(`[\x00-\x1f\\:*?"<>|]`)
+)
+
+// extensions of the files by the content type if the path has no extension.
+var extensions = map[string]string{
+	"text/html":              ".html",
+	"application/xhtml+xml":  ".html",
+	"text/css":               ".css",
+	"text/javascript":        ".js",
+	"application/javascript": ".js",
+	"application/json":       ".json",
+	"application/xml":        ".xml",
+	"text/xml":               ".xml",
+	"application/rss+xml":    ".xml",
+	"application/atom+xml":   ".xml",
+	"text/plain":             ".txt",
+	"application/pdf":        ".pdf",
+}
+
+// Mirror represents a mirror of the crawled pages in the directory.
+// The links in the HTML pages are rewritten on Close if RewriteLinks is set.
+type Mirror struct {
+	Dir          string
+	RewriteLinks bool
+	mu           sync.Mutex
+	files        map[string]string
+	owners       map[string]string
+	pages        []page
+}
+
+// page represents a saved HTML page.
+type page struct {
+	URL  *url.URL
+	file string
+}
+
+// New creates a new Mirror in the directory.
+func New(dir string) (*Mirror, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Mirror{
+		Dir:    dir,
+		files:  make(map[string]string),
+		owners: make(map[string]string),
+	}, nil
+}
+
+// Middleware returns the middleware of the queue which saves the successfully fetched pages.
+func (m *Mirror) Middleware() queue.Middleware {
+	return middleware.Parse(func(ctx context.Context, req *queue.Request, resp *http.Response, next middleware.ParseFunc) (parser.PageData, []string, error) {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return parser.PageData{}, nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		pageData, links, parseErr := next(ctx, req, resp)
+
+		if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+			_, err = m.Save(resp.Request.URL.String(), resp.Header.Get("Content-Type"), body, req.URL)
+			if err != nil {
+				return pageData, links, fmt.Errorf("can't save page to mirror: %s", err)
+			}
+		}
+
+		return pageData, links, parseErr
+	})
+}
+
+// Save saves the body of the page with the URL and the content type and returns the path of the file.
+// The aliases, e.g. the URLs redirected to the page, are mapped to the same file.
+func (m *Mirror) Save(URL string, contentType string, body []byte, aliases ...string) (string, error) {
+	u, err := url.Parse(URL)
+	if err != nil {
+		return "", err
+	}
+	u.Fragment = ""
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	isHTML := mediaType == "" || mediaType == "text/html" || mediaType == "application/xhtml+xml"
+
+	m.mu.Lock()
+	file, ok := m.files[u.String()]
+	if !ok {
+		file = m.claim(u.String(), LocalPath(u, mediaType))
+		m.files[u.String()] = file
+		if isHTML {
+			m.pages = append(m.pages, page{URL: u, file: file})
+		}
+	}
+	for _, alias := range aliases {
+		if a, err := url.Parse(alias); err == nil {
+			a.Fragment = ""
+			m.files[a.String()] = file
+		}
+	}
+	m.mu.Unlock()
+
+	filePath := filepath.Join(m.Dir, filepath.FromSlash(file))
+	err = os.MkdirAll(filepath.Dir(filePath), 0o755)
+	if err != nil {
+		return "", err
+	}
+
+	return filePath, os.WriteFile(filePath, body, 0o644)
+}
+
+// Close rewrites the links in the saved HTML pages if RewriteLinks is set.
+// The links to the saved pages are rewritten to the relative local paths,
+// the other relative links are rewritten to the absolute URLs.
+func (m *Mirror) Close() error {
+	if !m.RewriteLinks {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, p := range m.pages {
+		filePath := filepath.Join(m.Dir, filepath.FromSlash(p.file))
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(filePath, m.rewrite(p, content), 0o644)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LocalPath returns the slash-separated path of the file of the URL relative to the mirror directory:
+// "<host>/<path>". The HTML pages are saved as index.html in the directory of their path
+// unless the path has the .html extension, the extension of the other files is added by the content type
+// if the path has none. The query string is mapped to the hash in the file name.
+func LocalPath(u *url.URL, mediaType string) string {
+	isHTML := mediaType == "" || mediaType == "text/html" || mediaType == "application/xhtml+xml"
+
+	var dirs []string
+	for _, s := range strings.Split(u.Path, "/") {
+		if s == "" || s == "." || s == ".." {
+			continue
+		}
+		dirs = append(dirs, sanitize(s))
+	}
+
+	name := "index" + extensions[mediaType]
+	if isHTML {
+		name = "index.html"
+	}
+
+	if len(dirs) > 0 && !strings.HasSuffix(u.Path, "/") {
+		last := dirs[len(dirs)-1]
+		ext := strings.ToLower(path.Ext(last))
+
+		switch {
+		case isHTML && ext != ".html" && ext != ".htm":
+			// The page is saved as the index of the directory, so the pages under its path don't collide with it
+		case ext == "":
+			name = last + extensions[mediaType]
+			dirs = dirs[:len(dirs)-1]
+		default:
+			name = last
+			dirs = dirs[:len(dirs)-1]
+		}
+	}
+
+	if u.RawQuery != "" {
+		ext := path.Ext(name)
+		name = fmt.Sprintf("%s@%s%s", strings.TrimSuffix(name, ext), hash(u.RawQuery), ext)
+	}
+
+	host := sanitize(strings.ReplaceAll(u.Host, ":", "_"))
+
+	return path.Join(append(append([]string{host}, dirs...), name)...)
+}
+
+// claim returns the file for the URL adding a suffix if the file is already taken by another URL,
+// e.g. on case-insensitive file systems.
+func (m *Mirror) claim(URL string, file string) string {
+	ext := path.Ext(file)
+	base := strings.TrimSuffix(file, ext)
+
+	candidate := file
+	for i := 2; ; i++ {
+		key := strings.ToLower(candidate)
+		owner, taken := m.owners[key]
+		if !taken || owner == URL {
+			m.owners[key] = URL
+			return candidate
+		}
+
+		candidate = fmt.Sprintf("%s~%d%s", base, i, ext)
+	}
+}
+
+// rewrite rewrites the links of the page.
+func (m *Mirror) rewrite(p page, content []byte) []byte {
+	return regExLinkAttr.ReplaceAllFunc(content, func(match []byte) []byte {
+		parts := regExLinkAttr.FindSubmatch(match)
+		value := string(parts[3])
+
+		rewritten, ok := m.localLink(p, value)
+		if !ok {
+			return match
+		}
+
+		return []byte(string(parts[1]) + string(parts[2]) + rewritten + string(parts[4]))
+	})
+}
+
+// localLink returns the relative local path of the link if the page is saved,
+// the absolute URL if the link is relative, or false if it shouldn't be rewritten.
+func (m *Mirror) localLink(p page, link string) (string, bool) {
+	trimmed := strings.TrimSpace(link)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return "", false
+	}
+
+	ref, err := url.Parse(trimmed)
+	if err != nil {
+		return "", false
+	}
+	if ref.Scheme != "" && ref.Scheme != "http" && ref.Scheme != "https" {
+		return "", false
+	}
+
+	target := p.URL.ResolveReference(ref)
+	fragment := target.Fragment
+	target.Fragment = ""
+
+	file, ok := m.files[target.String()]
+	if !ok {
+		if ref.IsAbs() {
+			return "", false
+		}
+		target.Fragment = fragment
+		return target.String(), true
+	}
+
+	rel, err := filepath.Rel(filepath.Dir(filepath.FromSlash(p.file)), filepath.FromSlash(file))
+	if err != nil {
+		return "", false
+	}
+
+	local := (&url.URL{Path: filepath.ToSlash(rel)}).EscapedPath()
+	if fragment != "" {
+		local += "#" + url.PathEscape(fragment)
+	}
+
+	return local, true
+}
+
+// sanitize replaces the characters which aren't allowed in the file names on the common file systems
+// and shortens the long names keeping them unique.
+func sanitize(name string) string {
+	name = regExUnsafeChars.ReplaceAllString(name, "_")
+	if len(name) > maxSegmentLen {
+		ext := path.Ext(name)
+		if len(ext) > 10 {
+			ext = ""
+		}
+		name = name[:maxSegmentLen-len(ext)-9] + "@" + hash(name) + ext
+	}
+
+	return name
+}
+
+// hash returns the short hash of the value.
+func hash(value string) string {
+	sum := sha1.Sum([]byte(value))
+
+	return hex.EncodeToString(sum[:4])
+}
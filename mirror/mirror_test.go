@@ -0,0 +1,128 @@
+package mirror
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/demyanovs/urlcrawler/crawler"
+	"github.com/stretchr/testify/require"
+)
+
+const testMirrorDir = "test_mirror"
+
+func TestLocalPath_Success(t *testing.T) {
+	tests := []struct {
+		URL       string
+		mediaType string
+		expected  string
+	}{
+		{"https://example.com", "text/html", "example.com/index.html"},
+		{"https://example.com/", "", "example.com/index.html"},
+		{"https://example.com/docs/", "text/html", "example.com/docs/index.html"},
+		{"https://example.com/docs/intro", "text/html", "example.com/docs/intro/index.html"},
+		{"https://example.com/docs/page.php", "text/html", "example.com/docs/page.php/index.html"},
+		{"https://example.com/about.html", "text/html", "example.com/about.html"},
+		{"https://example.com/search?q=go&page=2", "text/html", "example.com/search/index@" + hash("q=go&page=2") + ".html"},
+		{"https://example.com:8080/feed", "application/rss+xml", "example.com_8080/feed.xml"},
+		{"https://example.com/files/report.pdf", "application/pdf", "example.com/files/report.pdf"},
+		{"https://example.com/robots.txt", "text/plain", "example.com/robots.txt"},
+		{"https://example.com/a%3Ab/c%3F.html", "text/html", "example.com/a_b/c_.html"},
+		{"https://example.com/../../etc/passwd", "text/plain", "example.com/etc/passwd.txt"},
+	}
+
+	for _, tt := range tests {
+		u, err := url.Parse(tt.URL)
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, LocalPath(u, tt.mediaType), tt.URL)
+	}
+}
+
+func TestLocalPath_LongNameSuccess(t *testing.T) {
+	u, err := url.Parse("https://example.com/" + strings.Repeat("a", 300) + ".pdf")
+	require.NoError(t, err)
+
+	p := LocalPath(u, "application/pdf")
+	name := filepath.Base(p)
+	require.Len(t, name, maxSegmentLen)
+	require.True(t, strings.HasSuffix(name, ".pdf"))
+}
+
+func TestSave_CollisionSuccess(t *testing.T) {
+	defer os.RemoveAll(testMirrorDir)
+
+	m, err := New(testMirrorDir)
+	require.NoError(t, err)
+
+	first, err := m.Save("https://example.com/Docs.html", "text/html", []byte("upper"))
+	require.NoError(t, err)
+	second, err := m.Save("https://example.com/docs.html", "text/html", []byte("lower"))
+	require.NoError(t, err)
+	again, err := m.Save("https://example.com/Docs.html#top", "text/html", []byte("upper again"))
+	require.NoError(t, err)
+
+	require.Equal(t, filepath.Join(testMirrorDir, "example.com", "Docs.html"), first)
+	require.Equal(t, filepath.Join(testMirrorDir, "example.com", "docs~2.html"), second)
+	require.Equal(t, first, again)
+}
+
+func TestMiddleware_RewriteLinksSuccess(t *testing.T) {
+	defer os.RemoveAll(testMirrorDir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><head><link href="/style.css" rel="stylesheet"></head><body>` +
+				`<a href="/docs/">Docs</a> <a href='about.html#team'>About</a> <a href="/old">Old</a> ` +
+				`<a href="https://example.org/">External</a> <a href="#top">Top</a> <a href="mailto:a@example.com">Mail</a>` +
+				`</body></html>`))
+		case "/docs/":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><body><a href="../">Home</a> <a href="/search?q=go">Search</a></body></html>`))
+		case "/about.html":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><body><a href="/">Home</a></body></html>`))
+		case "/search":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><body><a href="/docs/">Docs</a></body></html>`))
+		case "/old":
+			http.Redirect(w, r, "/about.html", http.StatusMovedPermanently)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	m, err := New(testMirrorDir)
+	require.NoError(t, err)
+	m.RewriteLinks = true
+
+	c, err := crawler.New(server.URL+"/", crawler.WithDelay(0), crawler.WithMiddleware(m.Middleware()))
+	require.NoError(t, err)
+	require.NoError(t, c.Run(context.Background()))
+	require.NoError(t, m.Close())
+
+	host := strings.ReplaceAll(strings.TrimPrefix(server.URL, "http://"), ":", "_")
+	read := func(file string) string {
+		content, err := os.ReadFile(filepath.Join(testMirrorDir, host, file))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	require.Equal(t, `<html><head><link href="`+server.URL+`/style.css" rel="stylesheet"></head><body>`+
+		`<a href="docs/index.html">Docs</a> <a href='about.html#team'>About</a> <a href="about.html">Old</a> `+
+		`<a href="https://example.org/">External</a> <a href="#top">Top</a> <a href="mailto:a@example.com">Mail</a>`+
+		`</body></html>`, read("index.html"))
+	require.Equal(t, `<html><body><a href="../index.html">Home</a> <a href="../search/index@`+hash("q=go")+`.html">Search</a></body></html>`, read("docs/index.html"))
+	require.Equal(t, `<html><body><a href="index.html">Home</a></body></html>`, read("about.html"))
+
+	// Not found pages aren't saved
+	_, err = os.Stat(filepath.Join(testMirrorDir, host, "style.css"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
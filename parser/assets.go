@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Types of the assets.
+const (
+	AssetImage      = "image"
+	AssetScript     = "script"
+	AssetStylesheet = "stylesheet"
+	AssetIcon       = "icon"
+	AssetPreload    = "preload"
+	AssetIframe     = "iframe"
+	AssetMedia      = "media"
+	AssetStyle      = "style"
+)
+
+var (
+	regExAssetTag = regexp.MustCompile(`(?is)<(img|script|link|iframe|source|video|audio)\b[^>]*>`)
+	regExStyle    = regexp.MustCompile(`(?is)<style[^>]*>(.*?)</style>|\sstyle\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	regExCSSURL   = regexp.MustCompile(`(?i)url\(\s*(?:"([^"]*)"|'([^']*)'|([^)'"]*))\s*\)`)
+
+	regExAssetAttrs = map[string]*regexp.Regexp{
+		"src":    attrRegExp("src"),
+		"srcset": attrRegExp("srcset"),
+		"href":   attrRegExp("href"),
+		"rel":    attrRegExp("rel"),
+		"poster": attrRegExp("poster"),
+	}
+)
+
+// Asset represents an asset referenced by the page.
+type Asset struct {
+	URL  string `json:"url"`
+	Type string `json:"type"`
+}
+
+// assets returns the unique assets of the page with the URLs resolved relative to the page.
+func (p *Parser) assets(base *url.URL, content string) []Asset {
+	var assets []Asset
+	seen := make(map[string]bool)
+	add := func(assetType string, rawURL string) {
+		rawURL = strings.TrimSpace(html.UnescapeString(rawURL))
+		if rawURL == "" || strings.HasPrefix(rawURL, "#") {
+			return
+		}
+
+		u, err := base.Parse(rawURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+
+		u.Fragment = ""
+		key := assetType + " " + u.String()
+		if seen[key] {
+			return
+		}
+
+		seen[key] = true
+		assets = append(assets, Asset{URL: u.String(), Type: assetType})
+	}
+
+	for _, m := range regExAssetTag.FindAllStringSubmatch(content, -1) {
+		tag, name := m[0], strings.ToLower(m[1])
+		switch name {
+		case "img":
+			add(AssetImage, attrValue(tag, "src"))
+			for _, u := range srcset(attrValue(tag, "srcset")) {
+				add(AssetImage, u)
+			}
+		case "script":
+			add(AssetScript, attrValue(tag, "src"))
+		case "link":
+			if assetType := linkAssetType(attrValue(tag, "rel")); assetType != "" {
+				add(assetType, attrValue(tag, "href"))
+			}
+		case "iframe":
+			add(AssetIframe, attrValue(tag, "src"))
+		case "source", "video", "audio":
+			add(AssetMedia, attrValue(tag, "src"))
+			for _, u := range srcset(attrValue(tag, "srcset")) {
+				add(AssetMedia, u)
+			}
+			add(AssetImage, attrValue(tag, "poster"))
+		}
+	}
+
+	for _, m := range regExStyle.FindAllStringSubmatch(content, -1) {
+		for _, u := range regExCSSURL.FindAllStringSubmatch(html.UnescapeString(m[1]+m[2]+m[3]), -1) {
+			add(AssetStyle, u[1]+u[2]+u[3])
+		}
+	}
+
+	return assets
+}
+
+func attrRegExp(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(?is)\s` + name + `\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+}
+
+// attrValue returns the value of the attribute of the tag.
+func attrValue(tag string, name string) string {
+	m := regExAssetAttrs[name].FindStringSubmatch(tag)
+	if len(m) == 0 {
+		return ""
+	}
+
+	return m[1] + m[2] + m[3]
+}
+
+// srcset returns the URLs of the image candidates.
+func srcset(value string) []string {
+	var URLs []string
+	for _, candidate := range strings.Split(value, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			URLs = append(URLs, fields[0])
+		}
+	}
+
+	return URLs
+}
+
+func linkAssetType(rel string) string {
+	for _, r := range strings.Fields(strings.ToLower(rel)) {
+		switch {
+		case r == "stylesheet":
+			return AssetStylesheet
+		case r == "icon" || r == "apple-touch-icon" || r == "mask-icon":
+			return AssetIcon
+		case r == "preload" || r == "modulepreload":
+			return AssetPreload
+		}
+	}
+
+	return ""
+}
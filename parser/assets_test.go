@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseURL_AssetsSuccess(t *testing.T) {
+	resp := newResponse("text/html", `<html><head>
+<link rel="stylesheet" href="/css/main.css?v=1">
+<link rel="shortcut icon" href="/favicon.ico">
+<link rel="preload" href="/fonts/font.woff2" as="font">
+<link rel="canonical" href="https://example.com/docs/page">
+<script src="https://cdn.example.net/app.js"></script>
+<script>var inline = true;</script>
+<style>.hero { background: url("/img/hero.jpg") } .logo { background: url(data:image/png;base64,AAAA) }</style>
+</head><body>
+<img src="img/a.png" srcset="img/a-2x.png 2x, img/a-3x.png 3x" alt="A">
+<img src='img/a.png' alt="A again">
+<div style="background-image: url('/img/bg.png#top')"></div>
+<iframe src="https://www.youtube.com/embed/1"></iframe>
+<video src="/video.mp4" poster="/poster.jpg"><source src="/video.webm"></video>
+<audio src="/audio.mp3"></audio>
+</body></html>`)
+
+	parser := New()
+	pageData, _, err := parser.ParseResponse(resp)
+	require.NoError(t, err)
+	require.Equal(t, []Asset{
+		{URL: "https://example.com/css/main.css?v=1", Type: AssetStylesheet},
+		{URL: "https://example.com/favicon.ico", Type: AssetIcon},
+		{URL: "https://example.com/fonts/font.woff2", Type: AssetPreload},
+		{URL: "https://cdn.example.net/app.js", Type: AssetScript},
+		{URL: "https://example.com/docs/img/a.png", Type: AssetImage},
+		{URL: "https://example.com/docs/img/a-2x.png", Type: AssetImage},
+		{URL: "https://example.com/docs/img/a-3x.png", Type: AssetImage},
+		{URL: "https://www.youtube.com/embed/1", Type: AssetIframe},
+		{URL: "https://example.com/video.mp4", Type: AssetMedia},
+		{URL: "https://example.com/poster.jpg", Type: AssetImage},
+		{URL: "https://example.com/video.webm", Type: AssetMedia},
+		{URL: "https://example.com/audio.mp3", Type: AssetMedia},
+		{URL: "https://example.com/img/hero.jpg", Type: AssetStyle},
+		{URL: "https://example.com/img/bg.png", Type: AssetStyle},
+	}, pageData.Assets)
+}
+
+func TestParseURL_HTTPLinksSuccess(t *testing.T) {
+	resp := newResponse("text/html", `<a href="http://example.com/a">A</a> <a href="HTTP://partner.com/?a=1&amp;b=2">B</a>
+<a href="https://example.com/c">C</a> <a href="/d">D</a> <a href="http://example.com/a">A again</a>`)
+
+	parser := New()
+	pageData, _, err := parser.ParseResponse(resp)
+	require.NoError(t, err)
+	require.Equal(t, []string{"http://example.com/a", "HTTP://partner.com/?a=1&b=2"}, pageData.HTTPLinks)
+
+	resp = newResponse("text/html", `<a href="http://example.com/a">A</a>`)
+	resp.Request.URL.Scheme = "http"
+	pageData, _, err = parser.ParseResponse(resp)
+	require.NoError(t, err)
+	require.Empty(t, pageData.HTTPLinks)
+}
@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"math/bits"
+	"strings"
+)
+
+// shingleSize represents a number of words in a shingle used for SimHash.
+const shingleSize = 3
+
+// fingerprint returns the SHA-256 hash of the normalized text
+// and its SimHash calculated over word shingles.
+func fingerprint(words []string) (string, uint64) {
+	if len(words) == 0 {
+		return "", 0
+	}
+
+	normalized := strings.Join(words, " ")
+	hash := sha256.Sum256([]byte(normalized))
+
+	return hex.EncodeToString(hash[:]), simHash(words)
+}
+
+func simHash(words []string) uint64 {
+	var weights [64]int
+
+	size := shingleSize
+	if len(words) < size {
+		size = len(words)
+	}
+
+	for i := 0; i+size <= len(words); i++ {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(strings.Join(words[i:i+size], " ")))
+		sum := h.Sum64()
+
+		for b := 0; b < 64; b++ {
+			if sum&(1<<b) != 0 {
+				weights[b]++
+			} else {
+				weights[b]--
+			}
+		}
+	}
+
+	var hash uint64
+	for b := 0; b < 64; b++ {
+		if weights[b] > 0 {
+			hash |= 1 << b
+		}
+	}
+
+	return hash
+}
+
+// Similarity returns the similarity of two SimHash fingerprints from 0 to 1.
+func Similarity(a, b uint64) float64 {
+	return 1 - float64(bits.OnesCount64(a^b))/64
+}
@@ -0,0 +1,30 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint_Success(t *testing.T) {
+	text := "the quick brown fox jumps over the lazy dog and runs away into the dark forest to find its den before night falls"
+
+	hash, simHash := fingerprint(strings.Fields(text))
+	sameHash, sameSimHash := fingerprint(strings.Fields(text))
+	require.Equal(t, hash, sameHash)
+	require.Equal(t, simHash, sameSimHash)
+
+	nearHash, nearSimHash := fingerprint(strings.Fields(strings.Replace(text, "night", "sunset", 1)))
+	require.NotEqual(t, hash, nearHash)
+	require.Greater(t, Similarity(simHash, nearSimHash), 0.8)
+
+	_, otherSimHash := fingerprint(strings.Fields("lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod tempor incididunt ut labore"))
+	require.Less(t, Similarity(simHash, otherSimHash), Similarity(simHash, nearSimHash))
+}
+
+func TestFingerprint_EmptySuccess(t *testing.T) {
+	hash, simHash := fingerprint(nil)
+	require.Empty(t, hash)
+	require.Zero(t, simHash)
+}
@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"html"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// SourceJSDiscovered is the source of the pages found by the JavaScript link discovery.
+const SourceJSDiscovered = "js-discovered"
+
+var (
+	regExScript   = regexp.MustCompile(`(?is)<script\b([^>]*)>(.*?)</script>`)
+	regExType     = regexp.MustCompile(`(?is)\stype\s*=\s*["']?([^"'\s>]+)`)
+	regExJSAttr   = regexp.MustCompile(`(?is)\s(data-href|data-url|data-link|onclick)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	regExJSString = regexp.MustCompile("[\"'`]((?:https?://|/)[^\"'`\\s<>]*)[\"'`]")
+
+	// jsSkipExtensions represents extensions of the static files which aren't pages.
+	jsSkipExtensions = []string{
+		".js", ".mjs", ".css", ".map", ".json", ".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", ".ico",
+		".woff", ".woff2", ".ttf", ".eot", ".mp4", ".webm", ".mp3",
+	}
+)
+
+// JSParser represents a parser for JavaScript files.
+// URL-like string literals are returned as the JS links if the discovery is enabled.
+type JSParser struct {
+	Parser
+}
+
+// ParseResponse parses the script and returns no links, the candidates are in the JS links of the page.
+func (p *JSParser) ParseResponse(resp *http.Response) (PageData, []string, error) {
+	pageData, content, err := p.response(resp)
+	if err != nil {
+		return pageData, nil, err
+	}
+
+	if p.DiscoverJSLinks {
+		pageData.JSLinks = p.sameHostLinks(resp.Request.URL, jsCandidates(string(content)))
+	}
+
+	return pageData, nil, nil
+}
+
+// jsLinks returns the same-host links found by the heuristics in the inline scripts and
+// in the data-href, data-url, data-link and onclick attributes, and the same-origin scripts to scan.
+// The links already found in the HTML links are skipped.
+func (p *Parser) jsLinks(base *url.URL, content string, links []string) ([]string, []string) {
+	var URLs, scripts []string
+	for _, m := range regExScript.FindAllStringSubmatch(content, -1) {
+		if src := attrValue(m[1], "src"); src != "" {
+			scripts = append(scripts, src)
+			continue
+		}
+
+		if t := regExType.FindStringSubmatch(m[1]); len(t) > 0 && !strings.Contains(strings.ToLower(t[1]), "javascript") && !strings.EqualFold(t[1], "module") {
+			continue
+		}
+
+		URLs = append(URLs, jsCandidates(m[2])...)
+	}
+
+	for _, m := range regExJSAttr.FindAllStringSubmatch(content, -1) {
+		value := html.UnescapeString(m[2] + m[3])
+		if strings.EqualFold(m[1], "onclick") {
+			URLs = append(URLs, jsCandidates(value)...)
+		} else if plausibleJSLink(value) {
+			URLs = append(URLs, value)
+		}
+	}
+
+	var jsLinks []string
+	for _, l := range p.sameHostLinks(base, URLs) {
+		if !slices.Contains(links, l) {
+			jsLinks = append(jsLinks, l)
+		}
+	}
+
+	return jsLinks, p.sameHostLinks(base, scripts)
+}
+
+// jsCandidates returns the URL-like string literals of the script which look like the pages,
+// e.g. router path definitions.
+func jsCandidates(script string) []string {
+	var candidates []string
+	for _, m := range regExJSString.FindAllStringSubmatch(script, -1) {
+		if plausibleJSLink(m[1]) {
+			candidates = append(candidates, m[1])
+		}
+	}
+
+	return candidates
+}
+
+func plausibleJSLink(value string) bool {
+	if value == "" || len(value) > 200 || strings.ContainsAny(value, "{}*\\<>^$|() ") {
+		return false
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+
+	// Route parameters like /users/:id
+	if strings.Contains(u.Path, "/:") {
+		return false
+	}
+
+	ext := strings.ToLower(path.Ext(u.Path))
+
+	return !slices.Contains(jsSkipExtensions, ext)
+}
@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseURL_JSLinksSuccess(t *testing.T) {
+	body := `<html><head>
+<script src="/static/app.js"></script>
+<script src="https://cdn.example.net/lib.js"></script>
+<script type="application/ld+json">{"url": "https://example.com/ld"}</script>
+<script>
+const routes = [
+  { path: '/about', component: About },
+  { path: "/users/:id", component: User },
+  { path: ` + "`/blog/${slug}`" + ` },
+];
+fetch("/api/items?page=2");
+const logo = "/img/logo.png";
+const re = /^\/admin/;
+const other = "https://other.com/page";
+const self = "https://example.com/pricing";
+</script>
+</head><body>
+<a href="/about">About</a>
+<div data-href="/contacts">Contacts</div>
+<button onclick="window.location.href='/signup'">Sign up</button>
+</body></html>`
+
+	parser := New()
+	parser.DiscoverJSLinks = true
+	pageData, links, err := parser.ParseResponse(newResponse("text/html", body))
+	require.NoError(t, err)
+	require.Equal(t, []string{"about"}, links)
+	require.Equal(t, []string{"api/items?page=2", "pricing", "contacts", "signup"}, pageData.JSLinks)
+	require.Equal(t, []string{"static/app.js"}, pageData.Scripts)
+
+	parser.DiscoverJSLinks = false
+	pageData, _, err = parser.ParseResponse(newResponse("text/html", body))
+	require.NoError(t, err)
+	require.Empty(t, pageData.JSLinks)
+	require.Empty(t, pageData.Scripts)
+}
+
+func TestJSParser_Success(t *testing.T) {
+	p := New()
+	p.DiscoverJSLinks = true
+	mux := NewMux(p)
+
+	pageData, links, err := mux.ParseResponse(newResponse("application/javascript; charset=utf-8",
+		`var r=[{path:"/dashboard"},{path:"/settings/profile"}];var s="/chunk.css";`))
+	require.NoError(t, err)
+	require.Empty(t, links)
+	require.Equal(t, []string{"dashboard", "settings/profile"}, pageData.JSLinks)
+}
@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ResponseParser represents a parser of the response.
+type ResponseParser interface {
+	ParseResponse(resp *http.Response) (PageData, []string, error)
+}
+
+// Mux represents a parser which selects the parser by the content type of the response.
+// The HTML parser is used if the response has no content type,
+// the body of the responses with unknown content types isn't parsed.
+type Mux struct {
+	html    *Parser
+	parsers map[string]ResponseParser
+}
+
+// NewMux creates a new Mux with the built-in parsers for HTML, RSS and Atom feeds,
+// sitemaps, plain text, JavaScript and PDF based on the given HTML parser.
+func NewMux(p Parser) *Mux {
+	feed := &FeedParser{Parser: p}
+	sitemap := &SitemapParser{Parser: p}
+
+	m := &Mux{
+		html:    &p,
+		parsers: make(map[string]ResponseParser),
+	}
+
+	m.Handle(m.html, "text/html", "application/xhtml+xml")
+	m.Handle(feed, "application/rss+xml", "application/atom+xml", "application/rdf+xml")
+	m.Handle(&xmlParser{feed: feed, sitemap: sitemap}, "application/xml", "text/xml")
+	m.Handle(&TextParser{Parser: p}, "text/plain")
+	m.Handle(&JSParser{Parser: p}, "application/javascript", "text/javascript", "application/x-javascript")
+	m.Handle(&PDFParser{Parser: p}, "application/pdf")
+
+	return m
+}
+
+// Handle registers the parser for the content types.
+func (m *Mux) Handle(p ResponseParser, contentTypes ...string) {
+	for _, contentType := range contentTypes {
+		m.parsers[strings.ToLower(contentType)] = p
+	}
+}
+
+// ParseResponse parses the response with the parser registered for its content type.
+func (m *Mux) ParseResponse(resp *http.Response) (PageData, []string, error) {
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		return m.html.ParseResponse(resp)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.TrimSpace(strings.Split(contentType, ";")[0])
+	}
+
+	if p, ok := m.parsers[strings.ToLower(mediaType)]; ok {
+		return p.ParseResponse(resp)
+	}
+
+	m.html.logger().Debug("body of unsupported content type is not parsed", "url", resp.Request.URL.String(), "content_type", mediaType)
+
+	pageData, _, err := m.html.response(resp)
+
+	return pageData, nil, err
+}
@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newResponse(contentType string, body string) *http.Response {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request: &http.Request{
+			URL: &url.URL{
+				Scheme: "https",
+				Host:   "example.com",
+				Path:   "/docs/page",
+			},
+		},
+	}
+}
+
+func TestMux_HTMLSuccess(t *testing.T) {
+	mux := NewMux(New())
+
+	for _, contentType := range []string{"", "text/html; charset=utf-8", "application/xhtml+xml"} {
+		pageData, links, err := mux.ParseResponse(newResponse(contentType, `<title>Page</title><a href="/a">A</a>`))
+		require.NoError(t, err)
+		require.Equal(t, "Page", pageData.Title)
+		require.Equal(t, []string{"a"}, links)
+	}
+}
+
+func TestMux_TextSuccess(t *testing.T) {
+	mux := NewMux(New())
+
+	pageData, links, err := mux.ParseResponse(newResponse("text/plain", "See https://example.com/a?b=1, "+
+		"https://example.com/c. and https://other.com/d\n(https://example.com/a?b=1)"))
+	require.NoError(t, err)
+	require.Equal(t, 6, pageData.WordCount)
+	require.NotEmpty(t, pageData.ContentHash)
+	require.Equal(t, []string{"a?b=1", "c"}, links)
+}
+
+func TestMux_UnknownContentTypeSuccess(t *testing.T) {
+	mux := NewMux(New())
+
+	pageData, links, err := mux.ParseResponse(newResponse("image/png", `<title>Page</title><a href="/a">A</a>`))
+	require.NoError(t, err)
+	require.Equal(t, "", pageData.Title)
+	require.Equal(t, "image/png", pageData.ContentType)
+	require.Equal(t, 37, pageData.Size)
+	require.Empty(t, links)
+}
+
+type stubParser struct{}
+
+func (p stubParser) ParseResponse(resp *http.Response) (PageData, []string, error) {
+	return PageData{URL: resp.Request.URL.String(), Title: "JSON"}, []string{"api"}, nil
+}
+
+func TestMux_HandleSuccess(t *testing.T) {
+	mux := NewMux(New())
+	mux.Handle(stubParser{}, "application/json")
+
+	pageData, links, err := mux.ParseResponse(newResponse("Application/JSON; charset=utf-8", `{}`))
+	require.NoError(t, err)
+	require.Equal(t, "JSON", pageData.Title)
+	require.Equal(t, []string{"api"}, links)
+}
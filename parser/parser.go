@@ -1,18 +1,34 @@
 package parser
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"html"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
+	"time"
 )
 
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
 var (
-	regExLinks    = regexp.MustCompile(`<a.*?href="/(.*?)[#"]`)
-	regExTitle    = regexp.MustCompile(`(?s)<title.*?>(.*?)</title>`)
-	regExDesc     = regexp.MustCompile("(?s)<meta.*?name=\"description\".*?content=\"(.*?)\"")
-	regExKeywords = regexp.MustCompile("(?s)<meta.*?name=\"keywords\".*?content=\"(.*?)\"")
+	regExLinks     = regexp.MustCompile(`<a.*?href="/(.*?)[#"]`)
+	regExTitle     = regexp.MustCompile(`(?s)<title.*?>(.*?)</title>`)
+	regExDesc      = regexp.MustCompile("(?s)<meta.*?name=\"description\".*?content=\"(.*?)\"")
+	regExKeywords  = regexp.MustCompile("(?s)<meta.*?name=\"keywords\".*?content=\"(.*?)\"")
+	regExCanonical = regexp.MustCompile(`(?is)<link[^>]*?rel="canonical"[^>]*?href="(.*?)"`)
+	regExH1        = regexp.MustCompile(`(?is)<h1[^>]*>(.*?)</h1>`)
+	regExNoText    = regexp.MustCompile(`(?is)<(script|style|noscript|template)[^>]*>.*?</(script|style|noscript|template)>|<!--.*?-->`)
+	regExTags      = regexp.MustCompile(`(?s)<[^>]*>`)
+	regExBody      = regexp.MustCompile(`(?is)<body[^>]*>(.*)</body>`)
+	regExLang      = regexp.MustCompile(`(?is)<html[^>]*?\slang="(.*?)"`)
+	regExImg       = regexp.MustCompile(`(?is)<img\b[^>]*>`)
+	regExAlt       = regexp.MustCompile(`(?is)\salt\s*=`)
 )
 
 // PagesData represents a slice of PageData.
@@ -20,16 +36,87 @@ type PagesData []PageData
 
 // PageData represents a data from HTML page.
 type PageData struct {
-	URL        string `json:"path"`
+	URL           string              `json:"path"`
+	StatusCode    int                 `json:"status code"`
+	Title         string              `json:"title"`
+	Desc          string              `json:"desc"`
+	Keywords      string              `json:"keywords"`
+	Checksum      string              `json:"checksum,omitempty"`
+	ContentHash   string              `json:"content hash,omitempty"`
+	SimHash       uint64              `json:"simhash,omitempty"`
+	Change        string              `json:"change,omitempty"`
+	Depth         int                 `json:"depth"`
+	ContentType   string              `json:"content type,omitempty"`
+	ResponseTime  int64               `json:"response time,omitempty"`
+	Size          int                 `json:"size,omitempty"`
+	Canonical     string              `json:"canonical,omitempty"`
+	H1            []string            `json:"h1,omitempty"`
+	WordCount     int                 `json:"word count,omitempty"`
+	Lang          string              `json:"lang,omitempty"`
+	Robots        string              `json:"robots,omitempty"`
+	NoIndex       bool                `json:"noindex,omitempty"`
+	NoFollow      bool                `json:"nofollow,omitempty"`
+	ImagesNoAlt   int                 `json:"images without alt,omitempty"`
+	Referrer      string              `json:"referrer,omitempty"`
+	Source        string              `json:"source,omitempty"`
+	Structured    *StructuredData     `json:"structured data,omitempty"`
+	Assets        []Asset             `json:"assets,omitempty"`
+	HTTPLinks     []string            `json:"http links,omitempty"`
+	Fields        map[string][]string `json:"fields,omitempty"`
+	Timing        Timing              `json:"timing"`
+	TLS           *TLS                `json:"tls,omitempty"`
+	Links         []string            `json:"links,omitempty"`
+	NofollowLinks []string            `json:"nofollow links,omitempty"`
+	JSLinks       []string            `json:"js links,omitempty"`
+	Scripts       []string            `json:"scripts,omitempty"`
+	Redirects     []Redirect          `json:"redirects,omitempty"`
+	Headers       http.Header         `json:"headers,omitempty"`
+	Error         string              `json:"error,omitempty"`
+}
+
+// TLS represents the TLS connection details and the certificate of the server.
+type TLS struct {
+	Version   string    `json:"version"`
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	NotBefore time.Time `json:"not before"`
+	NotAfter  time.Time `json:"not after"`
+	DNSNames  []string  `json:"dns names,omitempty"`
+}
+
+// Timing represents durations of the request phases.
+// DNS, Connect and TLS are zero when the connection is reused.
+type Timing struct {
+	DNS      time.Duration `json:"dns"`
+	Connect  time.Duration `json:"connect"`
+	TLS      time.Duration `json:"tls"`
+	TTFB     time.Duration `json:"ttfb"`
+	Download time.Duration `json:"download"`
+	Total    time.Duration `json:"total"`
+}
+
+// Redirect represents a redirect which was followed to get the page.
+type Redirect struct {
+	URL        string `json:"url"`
 	StatusCode int    `json:"status code"`
-	Title      string `json:"title"`
-	Desc       string `json:"desc"`
-	Keywords   string `json:"keywords"`
 }
 
 // Parser represents a parser for the page.
+// BotName is used to apply bot-specific meta robots and X-Robots-Tag directives.
+// Extractor extracts the user-defined fields if set.
+// DiscoverJSLinks enables the heuristic discovery of the links in the scripts.
+// Logger logs the problems of the pages which don't fail the parsing, nothing is logged if it's nil.
 type Parser struct {
-	Client http.Client
+	Client          http.Client
+	BotName         string
+	Extractor       Extractor
+	DiscoverJSLinks bool
+	Logger          *slog.Logger
+}
+
+// Extractor represents an extractor of the user-defined fields.
+type Extractor interface {
+	Extract(URL string, content []byte) map[string][]string
 }
 
 // New creates a new Parser.
@@ -41,21 +128,10 @@ func New() Parser {
 
 // ParseResponse parses the URL and returns the data from the page.
 func (p *Parser) ParseResponse(resp *http.Response) (PageData, []string, error) {
-	if resp.StatusCode != http.StatusOK {
-		return PageData{
-			URL:        resp.Request.URL.String(),
-			StatusCode: resp.StatusCode,
-		}, nil, fmt.Errorf("returned status: %s, url: %#v", resp.Status, resp.Request.URL.String())
-	}
-
-	content, err := io.ReadAll(resp.Body)
+	pageData, content, err := p.response(resp)
 	if err != nil {
-		return PageData{
-			URL:        resp.Request.URL.String(),
-			StatusCode: resp.StatusCode,
-		}, nil, fmt.Errorf("can't read response body, url: %#v. Error: %s", resp.Request.URL.String(), err)
+		return pageData, nil, err
 	}
-	defer resp.Body.Close()
 
 	contentString := string(content)
 
@@ -64,13 +140,95 @@ func (p *Parser) ParseResponse(resp *http.Response) (PageData, []string, error)
 	desc := p.description(contentString)
 	keywords := p.keywords(contentString)
 
-	return PageData{
-		URL:        resp.Request.URL.String(),
-		StatusCode: resp.StatusCode,
-		Title:      title,
-		Desc:       desc,
-		Keywords:   keywords,
-	}, p.unique(links), nil
+	pageData.Title = title
+	pageData.Desc = desc
+	pageData.Keywords = keywords
+	pageData.Canonical = p.canonical(contentString)
+	pageData.H1 = p.h1(contentString)
+	words := strings.Fields(strings.ToLower(p.text(contentString)))
+	pageData.WordCount = len(words)
+	pageData.ContentHash, pageData.SimHash = fingerprint(words)
+	pageData.Lang = p.lang(contentString)
+	p.setRobots(&pageData, append(p.headerRobots(resp.Header), p.metaRobots(contentString)...))
+	pageData.NofollowLinks = p.unique(p.nofollowLinks(contentString))
+	pageData.Structured = p.structuredData(contentString)
+	if pageData.Structured != nil {
+		for _, block := range pageData.Structured.JSONLD {
+			if block.Error != "" {
+				p.logger().Warn("invalid JSON-LD", "url", pageData.URL, "error", block.Error)
+			}
+		}
+	}
+	pageData.ImagesNoAlt = p.imagesWithoutAlt(contentString)
+	pageData.Assets = p.assets(resp.Request.URL, contentString)
+	if resp.Request.URL.Scheme == "https" {
+		pageData.HTTPLinks = p.httpLinks(contentString)
+	}
+
+	if p.DiscoverJSLinks {
+		pageData.JSLinks, pageData.Scripts = p.jsLinks(resp.Request.URL, contentString, links)
+	}
+
+	if p.Extractor != nil {
+		pageData.Fields = p.Extractor.Extract(pageData.URL, content)
+	}
+
+	return pageData, p.unique(links), nil
+}
+
+// logger returns the logger of the parser or the one discarding the records if it isn't set.
+func (p *Parser) logger() *slog.Logger {
+	if p.Logger == nil {
+		return discardLogger
+	}
+
+	return p.Logger
+}
+
+// response returns the data common for all the content types and the body of the response.
+func (p *Parser) response(resp *http.Response) (PageData, []byte, error) {
+	pageData := PageData{
+		URL:         resp.Request.URL.String(),
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Redirects:   p.redirects(resp),
+		Headers:     resp.Header,
+	}
+
+	if resp.ContentLength > 0 {
+		pageData.Size = int(resp.ContentLength)
+	}
+
+	p.setRobots(&pageData, p.headerRobots(resp.Header))
+
+	if resp.StatusCode != http.StatusOK {
+		return pageData, nil, fmt.Errorf("returned status: %s, url: %#v", resp.Status, resp.Request.URL.String())
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return pageData, nil, fmt.Errorf("can't read response body, url: %#v. Error: %s", resp.Request.URL.String(), err)
+	}
+	defer resp.Body.Close()
+
+	checksum := sha256.Sum256(content)
+	pageData.Checksum = hex.EncodeToString(checksum[:])
+	pageData.Size = len(content)
+
+	return pageData, content, nil
+}
+
+// redirects returns the redirects followed by the client in the order they happened.
+func (p *Parser) redirects(resp *http.Response) []Redirect {
+	var redirects []Redirect
+	for r := resp.Request.Response; r != nil && r.Request != nil; r = r.Request.Response {
+		redirects = append([]Redirect{{
+			URL:        r.Request.URL.String(),
+			StatusCode: r.StatusCode,
+		}}, redirects...)
+	}
+
+	return redirects
 }
 
 func (p *Parser) links(content string) []string {
@@ -87,6 +245,40 @@ func (p *Parser) links(content string) []string {
 	return links
 }
 
+// sameHostLinks resolves the URLs relative to the page and returns the links to the pages
+// on the same host in the form of the HTML links (the path without the leading slash).
+func (p *Parser) sameHostLinks(base *url.URL, URLs []string) []string {
+	var links []string
+	for _, rawURL := range URLs {
+		u, err := base.Parse(strings.TrimSpace(rawURL))
+		if err != nil || u.Host != base.Host || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+
+		link := strings.TrimPrefix(u.EscapedPath(), "/")
+		if u.RawQuery != "" {
+			link += "?" + u.RawQuery
+		}
+
+		links = append(links, link)
+	}
+
+	return p.unique(links)
+}
+
+// httpLinks returns the absolute links to the pages over plain HTTP.
+func (p *Parser) httpLinks(content string) []string {
+	var links []string
+	for _, a := range regExAnchor.FindAllString(content, -1) {
+		href := strings.TrimSpace(html.UnescapeString(attrValue(a, "href")))
+		if len(href) > 7 && strings.EqualFold(href[:7], "http://") {
+			links = append(links, href)
+		}
+	}
+
+	return p.unique(links)
+}
+
 func (p *Parser) title(content string) string {
 	matches := regExTitle.FindStringSubmatch(content)
 	if len(matches) == 0 {
@@ -114,6 +306,63 @@ func (p *Parser) keywords(content string) string {
 	return strings.TrimSpace(matches[1])
 }
 
+func (p *Parser) canonical(content string) string {
+	matches := regExCanonical.FindStringSubmatch(content)
+	if len(matches) == 0 {
+		return ""
+	}
+
+	return strings.TrimSpace(matches[1])
+}
+
+func (p *Parser) h1(content string) []string {
+	matches := regExH1.FindAllStringSubmatch(content, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	var h1 []string
+	for _, m := range matches {
+		h1 = append(h1, strings.Join(strings.Fields(html.UnescapeString(regExTags.ReplaceAllString(m[1], " "))), " "))
+	}
+
+	return h1
+}
+
+func (p *Parser) lang(content string) string {
+	matches := regExLang.FindStringSubmatch(content)
+	if len(matches) == 0 {
+		return ""
+	}
+
+	return strings.TrimSpace(matches[1])
+}
+
+// imagesWithoutAlt returns the number of images without the alt attribute.
+// Images with an empty alt are considered decorative.
+func (p *Parser) imagesWithoutAlt(content string) int {
+	count := 0
+	for _, img := range regExImg.FindAllString(content, -1) {
+		if !regExAlt.MatchString(img) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// text returns the visible text of the page body without tags, scripts and styles.
+func (p *Parser) text(content string) string {
+	if matches := regExBody.FindStringSubmatch(content); len(matches) > 0 {
+		content = matches[1]
+	}
+
+	content = regExNoText.ReplaceAllString(content, " ")
+	content = regExTags.ReplaceAllString(content, " ")
+
+	return html.UnescapeString(content)
+}
+
 func (p *Parser) unique(intSlice []string) []string {
 	keys := make(map[string]bool)
 	var list []string
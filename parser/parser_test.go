@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
 	"net/http"
 	"net/url"
@@ -48,13 +50,112 @@ func TestParseURL_Success(t *testing.T) {
 	parser := New()
 	pageData, linksOnPage, err := parser.ParseResponse(&resp)
 
+	checksum := sha256.Sum256([]byte(HTMLBodyWikiFyodorDostoevsky))
+
 	require.NoError(t, err)
 	require.Equal(t, 35, len(linksOnPage))
 	require.Equal(t, PageData{
-		URL:        "https://en.wikipedia.org/wiki/Fyodor_Dostoevsky",
-		StatusCode: 200,
-		Title:      "Fyodor Dostoevsky - Wikipedia",
-		Desc:       "Russian novelist, short story writer, essayist and journalist",
-		Keywords:   "Fyodor Dostoevsky, novelist, essayist, journalist",
+		URL:         "https://en.wikipedia.org/wiki/Fyodor_Dostoevsky",
+		StatusCode:  200,
+		Title:       "Fyodor Dostoevsky - Wikipedia",
+		Desc:        "Russian novelist, short story writer, essayist and journalist",
+		Keywords:    "Fyodor Dostoevsky, novelist, essayist, journalist",
+		Checksum:    hex.EncodeToString(checksum[:]),
+		ContentHash: "614ac76b51afa8f274aa7d7e2b5fdebabe3fd79af28e4663e28447e8e6c307c0",
+		SimHash:     0x7bd641d0f651ba68,
+		Size:        len(HTMLBodyWikiFyodorDostoevsky),
+		WordCount:   494,
+		NofollowLinks: []string{
+			"/upload.wikimedia.org/wikipedia/commons/transcoded/6/64/Ru-Dostoevsky.ogg/Ru-Dostoevsky.ogg.mp3",
+		},
+		Structured: &StructuredData{
+			OpenGraph: map[string]string{"og:title": "Fyodor Dostoevsky - Wikipedia"},
+		},
 	}, pageData)
 }
+
+func TestParseURL_CanonicalAndH1Success(t *testing.T) {
+	resp := http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+		Body: io.NopCloser(strings.NewReader(`<html><head>
+<link rel="canonical" href="https://example.com/page">
+<style>h1 {color: red}</style>
+</head><body><h1 class="title">Main <b>title</b></h1><h1>Second &amp; last</h1><script>var a = "no words";</script></body></html>`)),
+		Request: &http.Request{
+			URL: &url.URL{
+				Scheme: "https",
+				Host:   "example.com",
+				Path:   "/page",
+			},
+		},
+	}
+
+	parser := New()
+	pageData, _, err := parser.ParseResponse(&resp)
+
+	require.NoError(t, err)
+	require.Equal(t, "text/html; charset=utf-8", pageData.ContentType)
+	require.Equal(t, "https://example.com/page", pageData.Canonical)
+	require.Equal(t, []string{"Main title", "Second & last"}, pageData.H1)
+	require.Equal(t, 5, pageData.WordCount)
+}
+
+func TestParseURL_LangRobotsAndImagesSuccess(t *testing.T) {
+	resp := http.Response{
+		StatusCode: http.StatusOK,
+		Body: io.NopCloser(strings.NewReader(`<html class="page" lang="en"><head>
+<meta name="robots" content="NoIndex, follow">
+</head><body><img src="/a.png"><img src="/b.png" alt=""><img alt="C" src="/c.png"><IMG SRC="/d.png"></body></html>`)),
+		Request: &http.Request{
+			URL: &url.URL{
+				Scheme: "https",
+				Host:   "example.com",
+				Path:   "/page",
+			},
+		},
+	}
+
+	parser := New()
+	pageData, _, err := parser.ParseResponse(&resp)
+
+	require.NoError(t, err)
+	require.Equal(t, "en", pageData.Lang)
+	require.Equal(t, "noindex, follow", pageData.Robots)
+	require.Equal(t, 2, pageData.ImagesNoAlt)
+}
+
+func TestParseURL_RobotsDirectivesSuccess(t *testing.T) {
+	resp := http.Response{
+		StatusCode: http.StatusOK,
+		Header: http.Header{"X-Robots-Tag": {
+			"unavailable_after: 2030-01-01",
+			"otherbot: noindex",
+			"urlcrawler: nofollow",
+		}},
+		Body: io.NopCloser(strings.NewReader(`<html><head>
+<meta content="noarchive" name="robots">
+<meta name="otherbot" content="none">
+</head><body>
+<a href="/a">A</a><a rel="nofollow noopener" href="/b">B</a><a href="/c" rel="sponsored">C</a>
+</body></html>`)),
+		Request: &http.Request{
+			URL: &url.URL{
+				Scheme: "https",
+				Host:   "example.com",
+				Path:   "/page",
+			},
+		},
+	}
+
+	parser := New()
+	parser.BotName = "URLCrawler"
+	pageData, links, err := parser.ParseResponse(&resp)
+
+	require.NoError(t, err)
+	require.Equal(t, "unavailable_after: 2030-01-01, nofollow, noarchive", pageData.Robots)
+	require.False(t, pageData.NoIndex)
+	require.True(t, pageData.NoFollow)
+	require.Equal(t, []string{"a", "b", "c"}, links)
+	require.Equal(t, []string{"b"}, pageData.NofollowLinks)
+}
@@ -0,0 +1,166 @@
+package parser
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	// pdfMaxInflatedSize limits the size of the decompressed PDF streams.
+	pdfMaxInflatedSize = 10 << 20
+	pdfString          = `(\((?:\\[\s\S]|[^\\)])*\)|<[0-9A-Fa-f\s]*>)`
+)
+
+var (
+	regExPDFTitle    = regexp.MustCompile(`/Title\s*` + pdfString)
+	regExPDFSubject  = regexp.MustCompile(`/Subject\s*` + pdfString)
+	regExPDFKeywords = regexp.MustCompile(`/Keywords\s*` + pdfString)
+	regExPDFURI      = regexp.MustCompile(`/URI\s*` + pdfString)
+	regExPDFStream   = regexp.MustCompile(`stream\r?\n`)
+)
+
+// PDFParser represents a best-effort parser for PDF documents.
+// Title, subject and keywords are taken from the document information,
+// URI actions of the link annotations are followed.
+type PDFParser struct {
+	Parser
+}
+
+// ParseResponse parses the document and returns the links found in it.
+func (p *PDFParser) ParseResponse(resp *http.Response) (PageData, []string, error) {
+	pageData, content, err := p.response(resp)
+	if err != nil {
+		return pageData, nil, err
+	}
+
+	// The objects can be in the compressed object streams
+	data := append(content, pdfInflateStreams(content)...)
+
+	pageData.Title = pdfValue(regExPDFTitle, data)
+	pageData.Desc = pdfValue(regExPDFSubject, data)
+	pageData.Keywords = pdfValue(regExPDFKeywords, data)
+
+	var URLs []string
+	for _, m := range regExPDFURI.FindAllSubmatch(data, -1) {
+		URLs = append(URLs, pdfDecodeString(m[1]))
+	}
+
+	return pageData, p.sameHostLinks(resp.Request.URL, URLs), nil
+}
+
+func pdfValue(re *regexp.Regexp, data []byte) string {
+	m := re.FindSubmatch(data)
+	if len(m) == 0 {
+		return ""
+	}
+
+	return strings.TrimSpace(pdfDecodeString(m[1]))
+}
+
+// pdfInflateStreams returns the decompressed content of the Flate encoded streams.
+func pdfInflateStreams(content []byte) []byte {
+	var inflated []byte
+	for _, loc := range regExPDFStream.FindAllIndex(content, -1) {
+		end := bytes.Index(content[loc[1]:], []byte("endstream"))
+		if end == -1 {
+			break
+		}
+
+		r, err := zlib.NewReader(bytes.NewReader(content[loc[1] : loc[1]+end]))
+		if err != nil {
+			continue
+		}
+
+		data, _ := io.ReadAll(io.LimitReader(r, int64(pdfMaxInflatedSize-len(inflated))))
+		r.Close()
+
+		inflated = append(inflated, data...)
+		inflated = append(inflated, '\n')
+		if len(inflated) >= pdfMaxInflatedSize {
+			break
+		}
+	}
+
+	return inflated
+}
+
+// pdfDecodeString decodes the literal (in parentheses) or the hexadecimal (in angle brackets) PDF string.
+func pdfDecodeString(s []byte) string {
+	if len(s) < 2 {
+		return ""
+	}
+
+	var b []byte
+	if s[0] == '<' {
+		b, _ = hex.DecodeString(strings.Join(strings.Fields(string(s[1:len(s)-1])), ""))
+	} else {
+		b = pdfUnescape(s[1 : len(s)-1])
+	}
+
+	// UTF-16BE with the byte order mark
+	if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+		u := make([]uint16, 0, len(b)/2)
+		for i := 2; i+1 < len(b); i += 2 {
+			u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+
+		return string(utf16.Decode(u))
+	}
+
+	// PDFDocEncoding matches Latin-1 for the printable characters
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+
+	return string(runes)
+}
+
+func pdfUnescape(s []byte) []byte {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b = append(b, s[i])
+			continue
+		}
+
+		i++
+		switch c := s[i]; c {
+		case 'n':
+			b = append(b, '\n')
+		case 'r':
+			b = append(b, '\r')
+		case 't':
+			b = append(b, '\t')
+		case 'b':
+			b = append(b, '\b')
+		case 'f':
+			b = append(b, '\f')
+		case '\r', '\n':
+			// Line continuation
+			if c == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+		default:
+			if c >= '0' && c <= '7' {
+				var v byte
+				for n := 0; n < 3 && i < len(s) && s[i] >= '0' && s[i] <= '7'; n++ {
+					v = v*8 + s[i] - '0'
+					i++
+				}
+				i--
+				b = append(b, v)
+			} else {
+				b = append(b, c)
+			}
+		}
+	}
+
+	return b
+}
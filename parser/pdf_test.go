@@ -0,0 +1,42 @@
+package parser
+
+import (
+	"bytes"
+	"compress/zlib"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPDFParser_Success(t *testing.T) {
+	var stream bytes.Buffer
+	w := zlib.NewWriter(&stream)
+	_, err := w.Write([]byte(`<< /Type /Annot /Subtype /Link /A << /S /URI /URI (https://example.com/compressed) >> >>`))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	pdf := "%PDF-1.5\n" +
+		"1 0 obj\n<< /Title (Annual \\(2024\\) report) /Subject <FEFF0052006500730075006C00740073> /Keywords (report, caf\\351) >>\nendobj\n" +
+		"2 0 obj\n<< /Type /Annot /Subtype /Link /A << /S /URI /URI (/docs/guide.pdf) >> >>\nendobj\n" +
+		"3 0 obj\n<< /Type /Annot /Subtype /Link /A << /S /URI /URI (https://other.com/) >> >>\nendobj\n" +
+		"4 0 obj\n<< /Length " + strconv.Itoa(stream.Len()) + " /Filter /FlateDecode >>\nstream\n" + stream.String() + "\nendstream\nendobj\n" +
+		"%%EOF"
+
+	mux := NewMux(New())
+	pageData, links, err := mux.ParseResponse(newResponse("application/pdf", pdf))
+	require.NoError(t, err)
+	require.Equal(t, "Annual (2024) report", pageData.Title)
+	require.Equal(t, "Results", pageData.Desc)
+	require.Equal(t, "report, café", pageData.Keywords)
+	require.Equal(t, []string{"docs/guide.pdf", "compressed"}, links)
+}
+
+func TestPDFParser_NoMetadataSuccess(t *testing.T) {
+	parser := PDFParser{Parser: New()}
+
+	pageData, links, err := parser.ParseResponse(newResponse("application/pdf", "%PDF-1.4\n%%EOF"))
+	require.NoError(t, err)
+	require.Equal(t, "", pageData.Title)
+	require.Empty(t, links)
+}
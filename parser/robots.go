@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	regExMeta        = regexp.MustCompile(`(?is)<meta\b[^>]*>`)
+	regExMetaName    = regexp.MustCompile(`(?is)\sname\s*=\s*"(.*?)"`)
+	regExMetaContent = regexp.MustCompile(`(?is)\scontent\s*=\s*"(.*?)"`)
+	regExAnchor      = regexp.MustCompile(`(?is)<a\b[^>]*>`)
+	regExRel         = regexp.MustCompile(`(?is)\srel\s*=\s*"(.*?)"`)
+	regExHref        = regexp.MustCompile(`(?is)\shref\s*=\s*"/(.*?)[#"]`)
+)
+
+// robotsDirectivesWithValue represents X-Robots-Tag directives which have a value after a colon,
+// so they can't be confused with a bot name.
+var robotsDirectivesWithValue = []string{"unavailable_after", "max-snippet", "max-image-preview", "max-video-preview"}
+
+// metaRobots returns directives of the robots meta tag and the meta tag named after the bot.
+func (p *Parser) metaRobots(content string) []string {
+	var directives []string
+	for _, meta := range regExMeta.FindAllString(content, -1) {
+		name := regExMetaName.FindStringSubmatch(meta)
+		if len(name) == 0 || !p.appliesTo(name[1]) {
+			continue
+		}
+
+		metaContent := regExMetaContent.FindStringSubmatch(meta)
+		if len(metaContent) == 0 {
+			continue
+		}
+
+		directives = append(directives, splitDirectives(metaContent[1])...)
+	}
+
+	return directives
+}
+
+// headerRobots returns directives of the X-Robots-Tag headers applicable to all bots or to the bot.
+func (p *Parser) headerRobots(header http.Header) []string {
+	var directives []string
+	for _, value := range header.Values("X-Robots-Tag") {
+		bot, rest, found := strings.Cut(value, ":")
+		bot = strings.ToLower(strings.TrimSpace(bot))
+		if found && !slices.Contains(robotsDirectivesWithValue, bot) {
+			if !p.appliesTo(bot) {
+				continue
+			}
+			value = rest
+		}
+
+		directives = append(directives, splitDirectives(value)...)
+	}
+
+	return directives
+}
+
+func (p *Parser) setRobots(pageData *PageData, directives []string) {
+	directives = p.unique(directives)
+
+	pageData.Robots = strings.Join(directives, ", ")
+	pageData.NoIndex = slices.Contains(directives, "noindex") || slices.Contains(directives, "none")
+	pageData.NoFollow = slices.Contains(directives, "nofollow") || slices.Contains(directives, "none")
+}
+
+// appliesTo reports whether the meta tag or the header with the name applies to the bot.
+func (p *Parser) appliesTo(name string) bool {
+	name = strings.TrimSpace(name)
+
+	return strings.EqualFold(name, "robots") || (p.BotName != "" && strings.EqualFold(name, p.BotName))
+}
+
+// nofollowLinks returns links with rel="nofollow" in the same form as links.
+func (p *Parser) nofollowLinks(content string) []string {
+	var links []string
+	for _, a := range regExAnchor.FindAllString(content, -1) {
+		rel := regExRel.FindStringSubmatch(a)
+		if len(rel) == 0 || !slices.Contains(strings.Fields(strings.ToLower(rel[1])), "nofollow") {
+			continue
+		}
+
+		href := regExHref.FindStringSubmatch(a)
+		if len(href) == 0 {
+			continue
+		}
+
+		links = append(links, href[1])
+	}
+
+	return links
+}
+
+func splitDirectives(value string) []string {
+	var directives []string
+	for _, d := range strings.Split(value, ",") {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if d != "" {
+			directives = append(directives, d)
+		}
+	}
+
+	return directives
+}
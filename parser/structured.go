@@ -0,0 +1,298 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	regExJSONLD       = regexp.MustCompile(`(?is)<script[^>]*?type\s*=\s*"application/ld\+json"[^>]*>(.*?)</script>`)
+	regExMetaProperty = regexp.MustCompile(`(?is)\sproperty\s*=\s*"(.*?)"`)
+)
+
+// StructuredData represents structured data of the page.
+type StructuredData struct {
+	JSONLD    []JSONLD          `json:"json-ld,omitempty"`
+	Microdata []MicrodataItem   `json:"microdata,omitempty"`
+	OpenGraph map[string]string `json:"opengraph,omitempty"`
+	Twitter   map[string]string `json:"twitter,omitempty"`
+}
+
+// JSONLD represents a JSON-LD block.
+// Error is set if the block isn't a valid JSON.
+type JSONLD struct {
+	Data  json.RawMessage `json:"data,omitempty"`
+	Types []string        `json:"types,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// MicrodataItem represents a Microdata item.
+// Values of the properties are strings or nested items.
+type MicrodataItem struct {
+	Type       []string         `json:"type,omitempty"`
+	ID         string           `json:"id,omitempty"`
+	Properties map[string][]any `json:"properties,omitempty"`
+}
+
+// SchemaTypes returns unique types of JSON-LD and Microdata items.
+func (sd *StructuredData) SchemaTypes() []string {
+	if sd == nil {
+		return nil
+	}
+
+	var types []string
+	for _, block := range sd.JSONLD {
+		types = append(types, block.Types...)
+	}
+
+	for _, item := range sd.Microdata {
+		types = append(types, item.Type...)
+	}
+
+	var unique []string
+	for _, t := range types {
+		if !slices.Contains(unique, t) {
+			unique = append(unique, t)
+		}
+	}
+
+	return unique
+}
+
+func (p *Parser) structuredData(content string) *StructuredData {
+	sd := &StructuredData{
+		JSONLD:    p.jsonLD(content),
+		Microdata: p.microdata(content),
+	}
+
+	for _, meta := range regExMeta.FindAllString(content, -1) {
+		name := regExMetaProperty.FindStringSubmatch(meta)
+		if len(name) == 0 {
+			name = regExMetaName.FindStringSubmatch(meta)
+		}
+		metaContent := regExMetaContent.FindStringSubmatch(meta)
+		if len(name) == 0 || len(metaContent) == 0 {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSpace(name[1]))
+		value := html.UnescapeString(strings.TrimSpace(metaContent[1]))
+		switch {
+		case strings.HasPrefix(key, "og:"):
+			sd.OpenGraph = addMeta(sd.OpenGraph, key, value)
+		case strings.HasPrefix(key, "twitter:"):
+			sd.Twitter = addMeta(sd.Twitter, key, value)
+		}
+	}
+
+	if sd.JSONLD == nil && sd.Microdata == nil && sd.OpenGraph == nil && sd.Twitter == nil {
+		return nil
+	}
+
+	return sd
+}
+
+// addMeta adds the value to the meta tags keeping the first value of repeated tags.
+func addMeta(m map[string]string, key string, value string) map[string]string {
+	if m == nil {
+		m = make(map[string]string)
+	}
+
+	if _, ok := m[key]; !ok {
+		m[key] = value
+	}
+
+	return m
+}
+
+func (p *Parser) jsonLD(content string) []JSONLD {
+	var blocks []JSONLD
+	for _, m := range regExJSONLD.FindAllStringSubmatch(content, -1) {
+		raw := []byte(strings.TrimSpace(m[1]))
+
+		var data any
+		err := json.Unmarshal(raw, &data)
+		if err != nil {
+			blocks = append(blocks, JSONLD{Error: err.Error()})
+			continue
+		}
+
+		var compacted bytes.Buffer
+		_ = json.Compact(&compacted, raw)
+
+		blocks = append(blocks, JSONLD{
+			Data:  compacted.Bytes(),
+			Types: jsonLDTypes(data, nil),
+		})
+	}
+
+	return blocks
+}
+
+// jsonLDTypes collects @type values of the JSON-LD data including nested objects.
+func jsonLDTypes(data any, types []string) []string {
+	switch v := data.(type) {
+	case []any:
+		for _, item := range v {
+			types = jsonLDTypes(item, types)
+		}
+	case map[string]any:
+		switch t := v["@type"].(type) {
+		case string:
+			if !slices.Contains(types, t) {
+				types = append(types, t)
+			}
+		case []any:
+			for _, item := range t {
+				if s, ok := item.(string); ok && !slices.Contains(types, s) {
+					types = append(types, s)
+				}
+			}
+		}
+
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			if k != "@type" {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+
+		for _, k := range keys {
+			types = jsonLDTypes(v[k], types)
+		}
+	}
+
+	return types
+}
+
+func (p *Parser) microdata(content string) []MicrodataItem {
+	if !strings.Contains(content, "itemscope") {
+		return nil
+	}
+
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return nil
+	}
+
+	var items []MicrodataItem
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && hasAttr(n, "itemscope") && !hasAttr(n, "itemprop") {
+			items = append(items, microdataItem(n))
+			return
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	return items
+}
+
+func microdataItem(n *html.Node) MicrodataItem {
+	item := MicrodataItem{
+		Type: strings.Fields(attr(n, "itemtype")),
+		ID:   attr(n, "itemid"),
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+
+			if names := strings.Fields(attr(c, "itemprop")); len(names) > 0 {
+				var value any
+				if hasAttr(c, "itemscope") {
+					value = microdataItem(c)
+				} else {
+					value = microdataValue(c)
+				}
+
+				if item.Properties == nil {
+					item.Properties = make(map[string][]any)
+				}
+				for _, name := range names {
+					item.Properties[name] = append(item.Properties[name], value)
+				}
+
+				// Properties of the nested item belong to it
+				if hasAttr(c, "itemscope") {
+					continue
+				}
+			}
+
+			walk(c)
+		}
+	}
+	walk(n)
+
+	return item
+}
+
+// microdataValue returns the property value of the element according to the Microdata spec.
+func microdataValue(n *html.Node) string {
+	switch n.DataAtom {
+	case atom.Meta:
+		return attr(n, "content")
+	case atom.Audio, atom.Embed, atom.Iframe, atom.Img, atom.Source, atom.Track, atom.Video:
+		return attr(n, "src")
+	case atom.A, atom.Area, atom.Link:
+		return attr(n, "href")
+	case atom.Object:
+		return attr(n, "data")
+	case atom.Data, atom.Meter:
+		return attr(n, "value")
+	case atom.Time:
+		if hasAttr(n, "datetime") {
+			return attr(n, "datetime")
+		}
+	}
+
+	return strings.Join(strings.Fields(textContent(n)), " ")
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(textContent(c))
+		sb.WriteString(" ")
+	}
+
+	return sb.String()
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+
+	return ""
+}
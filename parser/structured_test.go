@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var HTMLBodyStructuredData = `<html><head>
+<meta property="og:title" content="Crime and Punishment">
+<meta property="og:image" content="https://example.com/cover.jpg">
+<meta property="og:image" content="https://example.com/cover2.jpg">
+<meta name="twitter:card" content="summary">
+<script type="application/ld+json">
+{"@context": "https://schema.org", "@graph": [{"@type": "Book", "name": "Crime and Punishment"}, {"@type": ["Person", "Thing"], "name": "Fyodor Dostoevsky"}]}
+</script>
+<script type="application/ld+json">{"@type": "Broken",}</script>
+</head><body>
+<div itemscope itemtype="https://schema.org/Book" itemid="urn:isbn:0140449132">
+	<h1 itemprop="name">Crime and <i>Punishment</i></h1>
+	<div itemprop="author" itemscope itemtype="https://schema.org/Person">
+		<span itemprop="name">Fyodor Dostoevsky</span>
+	</div>
+	<time itemprop="datePublished" datetime="1866">1866</time>
+	<a itemprop="url sameAs" href="https://example.com/book">Book</a>
+</div>
+</body></html>`
+
+func TestStructuredData_Success(t *testing.T) {
+	parser := New()
+	sd := parser.structuredData(HTMLBodyStructuredData)
+
+	require.NotNil(t, sd)
+	require.Equal(t, map[string]string{
+		"og:title": "Crime and Punishment",
+		"og:image": "https://example.com/cover.jpg",
+	}, sd.OpenGraph)
+	require.Equal(t, map[string]string{"twitter:card": "summary"}, sd.Twitter)
+
+	require.Len(t, sd.JSONLD, 2)
+	require.Equal(t, []string{"Book", "Person", "Thing"}, sd.JSONLD[0].Types)
+	require.True(t, json.Valid(sd.JSONLD[0].Data))
+	require.NotEmpty(t, sd.JSONLD[1].Error)
+	require.Nil(t, sd.JSONLD[1].Data)
+
+	require.Equal(t, []MicrodataItem{
+		{
+			Type: []string{"https://schema.org/Book"},
+			ID:   "urn:isbn:0140449132",
+			Properties: map[string][]any{
+				"name": {"Crime and Punishment"},
+				"author": {MicrodataItem{
+					Type:       []string{"https://schema.org/Person"},
+					Properties: map[string][]any{"name": {"Fyodor Dostoevsky"}},
+				}},
+				"datePublished": {"1866"},
+				"url":           {"https://example.com/book"},
+				"sameAs":        {"https://example.com/book"},
+			},
+		},
+	}, sd.Microdata)
+
+	require.Equal(t, []string{"Book", "Person", "Thing", "https://schema.org/Book"}, sd.SchemaTypes())
+}
+
+func TestStructuredData_EmptySuccess(t *testing.T) {
+	parser := New()
+	require.Nil(t, parser.structuredData("<html><body>No structured data</body></html>"))
+}
+
+func TestParseURL_InvalidJSONLDLogged(t *testing.T) {
+	resp := http.Response{
+		StatusCode: http.StatusOK,
+		Body: io.NopCloser(strings.NewReader(`<html><head>
+			<script type="application/ld+json">{"@type": "Book",</script></head></html>`)),
+		Request: &http.Request{
+			URL: &url.URL{Scheme: "https", Host: "example.com", Path: "/book"},
+		},
+	}
+
+	var logs bytes.Buffer
+	parser := New()
+	parser.Logger = slog.New(slog.NewJSONHandler(&logs, nil))
+
+	_, _, err := parser.ParseResponse(&resp)
+	require.NoError(t, err)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &record))
+	require.Equal(t, "WARN", record["level"])
+	require.Equal(t, "invalid JSON-LD", record["msg"])
+	require.Equal(t, "https://example.com/book", record["url"])
+}
@@ -0,0 +1,36 @@
+package parser
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+var regExTextURL = regexp.MustCompile(`https?://[^\s<>"'()\[\]{}]+`)
+
+// TextParser represents a parser for plain text.
+// Absolute URLs found in the text are followed.
+type TextParser struct {
+	Parser
+}
+
+// ParseResponse parses the text and returns the links found in it.
+func (p *TextParser) ParseResponse(resp *http.Response) (PageData, []string, error) {
+	pageData, content, err := p.response(resp)
+	if err != nil {
+		return pageData, nil, err
+	}
+
+	contentString := string(content)
+	words := strings.Fields(strings.ToLower(contentString))
+	pageData.WordCount = len(words)
+	pageData.ContentHash, pageData.SimHash = fingerprint(words)
+
+	var URLs []string
+	for _, u := range regExTextURL.FindAllString(contentString, -1) {
+		// Skip the punctuation at the end of the sentence
+		URLs = append(URLs, strings.TrimRight(u, ".,;:!?"))
+	}
+
+	return pageData, p.sameHostLinks(resp.Request.URL, URLs), nil
+}
@@ -0,0 +1,188 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// xmlPeekSize is the size of the beginning of the XML document to find the root element in.
+const xmlPeekSize = 4096
+
+// FeedParser represents a parser for RSS and Atom feeds.
+// Links of the feed items are followed.
+type FeedParser struct {
+	Parser
+}
+
+// SitemapParser represents a parser for sitemaps and sitemap indexes.
+// URLs of the pages and the nested sitemaps are followed.
+type SitemapParser struct {
+	Parser
+}
+
+// xmlParser parses the generic XML responses with the feed or the sitemap parser by the root element.
+type xmlParser struct {
+	feed    *FeedParser
+	sitemap *SitemapParser
+}
+
+type rssFeed struct {
+	Channel struct {
+		Title       string `xml:"title"`
+		Description string `xml:"description"`
+		Items       []struct {
+			Link string `xml:"link"`
+		} `xml:"item"`
+	} `xml:"channel"`
+	// RSS 1.0 items are the siblings of the channel
+	Items []struct {
+		Link string `xml:"link"`
+	} `xml:"item"`
+}
+
+type atomFeed struct {
+	Title    string     `xml:"title"`
+	Subtitle string     `xml:"subtitle"`
+	Links    []atomLink `xml:"link"`
+	Entries  []struct {
+		Links []atomLink `xml:"link"`
+	} `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type sitemap struct {
+	URLs []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+// ParseResponse parses the feed and returns its title, description and the links of the items.
+func (p *FeedParser) ParseResponse(resp *http.Response) (PageData, []string, error) {
+	pageData, content, err := p.response(resp)
+	if err != nil {
+		return pageData, nil, err
+	}
+
+	root, err := xmlRoot(content)
+	if err != nil {
+		return pageData, nil, fmt.Errorf("can't parse feed, url: %#v. Error: %s", pageData.URL, err)
+	}
+
+	var URLs []string
+	if root == "feed" {
+		var feed atomFeed
+		err = decodeXML(content, &feed)
+		if err != nil {
+			return pageData, nil, fmt.Errorf("can't parse feed, url: %#v. Error: %s", pageData.URL, err)
+		}
+
+		pageData.Title = strings.TrimSpace(feed.Title)
+		pageData.Desc = strings.TrimSpace(feed.Subtitle)
+		for _, entry := range feed.Entries {
+			for _, l := range entry.Links {
+				if l.Rel == "" || l.Rel == "alternate" {
+					URLs = append(URLs, l.Href)
+				}
+			}
+		}
+	} else {
+		var feed rssFeed
+		err = decodeXML(content, &feed)
+		if err != nil {
+			return pageData, nil, fmt.Errorf("can't parse feed, url: %#v. Error: %s", pageData.URL, err)
+		}
+
+		pageData.Title = strings.TrimSpace(feed.Channel.Title)
+		pageData.Desc = strings.TrimSpace(feed.Channel.Description)
+		for _, item := range append(feed.Channel.Items, feed.Items...) {
+			URLs = append(URLs, item.Link)
+		}
+	}
+
+	return pageData, p.sameHostLinks(resp.Request.URL, URLs), nil
+}
+
+// ParseResponse parses the sitemap and returns the URLs of the pages and the nested sitemaps.
+func (p *SitemapParser) ParseResponse(resp *http.Response) (PageData, []string, error) {
+	pageData, content, err := p.response(resp)
+	if err != nil {
+		return pageData, nil, err
+	}
+
+	var s sitemap
+	err = decodeXML(content, &s)
+	if err != nil {
+		return pageData, nil, fmt.Errorf("can't parse sitemap, url: %#v. Error: %s", pageData.URL, err)
+	}
+
+	var URLs []string
+	for _, u := range s.URLs {
+		URLs = append(URLs, u.Loc)
+	}
+	for _, u := range s.Sitemaps {
+		URLs = append(URLs, u.Loc)
+	}
+
+	return pageData, p.sameHostLinks(resp.Request.URL, URLs), nil
+}
+
+// ParseResponse parses the sitemaps and the feeds, other XML documents aren't parsed.
+func (p *xmlParser) ParseResponse(resp *http.Response) (PageData, []string, error) {
+	// Peek the beginning of the document to find the root element
+	body := bufio.NewReaderSize(resp.Body, xmlPeekSize)
+	content, _ := body.Peek(xmlPeekSize)
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{body, resp.Body}
+
+	root, _ := xmlRoot(content)
+	switch root {
+	case "urlset", "sitemapindex":
+		return p.sitemap.ParseResponse(resp)
+	case "rss", "feed", "RDF":
+		return p.feed.ParseResponse(resp)
+	}
+
+	pageData, _, err := p.feed.response(resp)
+
+	return pageData, nil, err
+}
+
+// decodeXML decodes the XML document in any of the supported charsets.
+func decodeXML(content []byte, v any) error {
+	d := xml.NewDecoder(bytes.NewReader(content))
+	d.CharsetReader = charset.NewReaderLabel
+
+	return d.Decode(v)
+}
+
+// xmlRoot returns the local name of the root element of the XML document.
+func xmlRoot(content []byte) (string, error) {
+	d := xml.NewDecoder(bytes.NewReader(content))
+	d.CharsetReader = charset.NewReaderLabel
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return "", err
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
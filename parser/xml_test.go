@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedParser_RSSSuccess(t *testing.T) {
+	mux := NewMux(New())
+
+	pageData, links, err := mux.ParseResponse(newResponse("application/rss+xml", `<?xml version="1.0" encoding="ISO-8859-1"?>
+<rss version="2.0"><channel>
+<title>News</title><description>Latest news</description>
+<item><title>First</title><link>https://example.com/news/first</link></item>
+<item><title>Second</title><link>/news/second</link></item>
+<item><title>Other</title><link>https://other.com/news</link></item>
+</channel></rss>`))
+	require.NoError(t, err)
+	require.Equal(t, "News", pageData.Title)
+	require.Equal(t, "Latest news", pageData.Desc)
+	require.Equal(t, []string{"news/first", "news/second"}, links)
+}
+
+func TestFeedParser_AtomSuccess(t *testing.T) {
+	mux := NewMux(New())
+
+	pageData, links, err := mux.ParseResponse(newResponse("application/xml", `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+<title>Blog</title><subtitle>Posts</subtitle>
+<link href="https://example.com/feed" rel="self"/>
+<entry><link href="https://example.com/posts/1"/><link rel="edit" href="https://example.com/edit/1"/></entry>
+<entry><link rel="alternate" href="posts/2"/></entry>
+</feed>`))
+	require.NoError(t, err)
+	require.Equal(t, "Blog", pageData.Title)
+	require.Equal(t, "Posts", pageData.Desc)
+	require.Equal(t, []string{"posts/1", "docs/posts/2"}, links)
+}
+
+func TestSitemapParser_Success(t *testing.T) {
+	mux := NewMux(New())
+
+	_, links, err := mux.ParseResponse(newResponse("text/xml", `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>https://example.com/</loc></url>
+<url><loc>https://example.com/about?lang=en</loc></url>
+</urlset>`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"", "about?lang=en"}, links)
+
+	_, links, err = mux.ParseResponse(newResponse("application/xml", `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<sitemap><loc>https://example.com/sitemap-posts.xml</loc></sitemap>
+</sitemapindex>`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"sitemap-posts.xml"}, links)
+}
+
+func TestSitemapParser_InvalidXMLError(t *testing.T) {
+	parser := SitemapParser{Parser: New()}
+
+	_, _, err := parser.ParseResponse(newResponse("application/xml", `<urlset><url>`))
+	require.Error(t, err)
+}
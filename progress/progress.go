@@ -0,0 +1,336 @@
+// Package progress displays the live progress of the crawl: the counts of the pages, the crawl rate,
+// the error rate, the distribution of the status codes and the downloaded bytes.
+// The display is updated in place in the terminal, otherwise the summary lines are printed periodically.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/demyanovs/urlcrawler/queue"
+)
+
+// DefaultInterval is the default interval of the summary lines if the output isn't a terminal.
+const DefaultInterval = 10 * time.Second
+
+// refreshInterval is the interval of the updates of the display in the terminal.
+const refreshInterval = 250 * time.Millisecond
+
+const (
+	cursorUp     = "\033[%dA"
+	clearToEnd   = "\r\033[J"
+	statusFailed = 0
+)
+
+// Snapshot represents the progress of the crawl at the moment.
+type Snapshot struct {
+	queue.Stats
+	// Pages is the number of the processed pages.
+	Pages int
+	// Failed is the number of the pages with an error.
+	Failed int
+	// Statuses is the number of the pages by the class of the status code, e.g. 2 for 2xx,
+	// 0 is the number of the requests without a response.
+	Statuses map[int]int
+	// Bytes is the size of the downloaded bodies of the pages.
+	Bytes int64
+	// Limit is the maximum number of the crawled pages, 0 is unlimited.
+	Limit int
+}
+
+// Progress represents the live progress of the crawl written to the output.
+// The pages are passed to Page, e.g. with the OnPage hook, the counts of the queue are taken from the stats function.
+type Progress struct {
+	Interval time.Duration
+	Limit    int
+	out      io.Writer
+	terminal bool
+	stats    func() queue.Stats
+	mu       sync.Mutex
+	pages    int
+	failed   int
+	statuses map[int]int
+	bytes    int64
+	lines    int
+	stopped  bool
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// New creates a new Progress written to the output, updated in place if the output is a terminal.
+func New(out io.Writer, terminal bool, stats func() queue.Stats) *Progress {
+	return &Progress{
+		Interval: DefaultInterval,
+		out:      out,
+		terminal: terminal,
+		stats:    stats,
+		statuses: make(map[int]int),
+	}
+}
+
+// IsTerminal reports whether the file is a terminal.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Page counts the processed page.
+func (p *Progress) Page(page parser.PageData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pages++
+	p.bytes += int64(page.Size)
+	p.statuses[page.StatusCode/100]++
+	if page.Error != "" {
+		p.failed++
+	}
+}
+
+// Snapshot returns the current progress.
+func (p *Progress) Snapshot() Snapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.snapshot()
+}
+
+// Start starts updating the progress until Stop is called.
+func (p *Progress) Start() {
+	interval := p.Interval
+	if p.terminal {
+		interval = refreshInterval
+	}
+
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				p.mu.Lock()
+				p.draw()
+				p.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// Stop stops updating the progress and writes the final one.
+func (p *Progress) Stop() {
+	if p.stop != nil {
+		close(p.stop)
+		<-p.done
+		p.stop = nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.draw()
+	p.stopped = true
+	p.lines = 0
+}
+
+// Writer returns the writer which writes to w above the display in the terminal,
+// so the log lines don't break it. The writes are passed through as is if the output isn't a terminal.
+// w must write to the same terminal as the output of the progress, e.g. both to stderr.
+func (p *Progress) Writer(w io.Writer) io.Writer {
+	if !p.terminal {
+		return w
+	}
+
+	return &writer{p: p, w: w}
+}
+
+// draw writes the progress: redraws the display in the terminal or writes the summary line otherwise.
+func (p *Progress) draw() {
+	s := p.snapshot()
+
+	if !p.terminal {
+		fmt.Fprintln(p.out, s.String())
+		return
+	}
+
+	p.clear()
+	lines := s.Lines()
+	for _, l := range lines {
+		fmt.Fprintln(p.out, l)
+	}
+	p.lines = len(lines)
+}
+
+// clear clears the display in the terminal.
+func (p *Progress) clear() {
+	if p.lines > 0 {
+		fmt.Fprintf(p.out, cursorUp+clearToEnd, p.lines)
+	}
+	p.lines = 0
+}
+
+func (p *Progress) snapshot() Snapshot {
+	var stats queue.Stats
+	if p.stats != nil {
+		stats = p.stats()
+	}
+
+	statuses := make(map[int]int, len(p.statuses))
+	for class, n := range p.statuses {
+		statuses[class] = n
+	}
+
+	return Snapshot{
+		Stats:    stats,
+		Pages:    p.pages,
+		Failed:   p.failed,
+		Statuses: statuses,
+		Bytes:    p.bytes,
+		Limit:    p.Limit,
+	}
+}
+
+// Rate returns the number of the crawled pages per second.
+func (s Snapshot) Rate() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+
+	return float64(s.Done) / s.Elapsed.Seconds()
+}
+
+// ErrorRate returns the share of the pages with an error from 0 to 1.
+func (s Snapshot) ErrorRate() float64 {
+	if s.Pages == 0 {
+		return 0
+	}
+
+	return float64(s.Failed) / float64(s.Pages)
+}
+
+// ETA returns the estimated time to crawl the queued pages, or the remaining ones up to the limit,
+// at the current rate. It returns false if the rate is unknown yet.
+func (s Snapshot) ETA() (time.Duration, bool) {
+	rate := s.Rate()
+	if rate == 0 {
+		return 0, false
+	}
+
+	remaining := s.ToDo + s.InProgress
+	if s.Limit > 0 {
+		remaining = min(remaining, max(s.Limit-s.Done, 0))
+	}
+
+	return time.Duration(float64(remaining) / rate * float64(time.Second)), true
+}
+
+// Lines returns the lines of the display in the terminal.
+func (s Snapshot) Lines() []string {
+	return []string{
+		fmt.Sprintf("Done: %d | Queued: %d | In flight: %d | Errors: %.1f%%", s.Done, s.ToDo, s.InProgress, s.ErrorRate()*100),
+		fmt.Sprintf("Statuses: %s", s.statuses()),
+		fmt.Sprintf("Downloaded: %s | %.1f pages/s | Elapsed: %s | ETA: %s", formatBytes(s.Bytes), s.Rate(), formatDuration(s.Elapsed), s.eta()),
+	}
+}
+
+// String returns the summary line of the progress.
+func (s Snapshot) String() string {
+	return fmt.Sprintf(
+		"progress: done %d, queued %d, in flight %d, errors %.1f%%, statuses %s, downloaded %s, %.1f pages/s, elapsed %s, ETA %s",
+		s.Done,
+		s.ToDo,
+		s.InProgress,
+		s.ErrorRate()*100,
+		s.statuses(),
+		formatBytes(s.Bytes),
+		s.Rate(),
+		formatDuration(s.Elapsed),
+		s.eta(),
+	)
+}
+
+// statuses returns the distribution of the status codes, e.g. "2xx 10, 4xx 1, failed 2".
+func (s Snapshot) statuses() string {
+	var parts []string
+	for class := 1; class <= 5; class++ {
+		if n := s.Statuses[class]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%dxx %d", class, n))
+		}
+	}
+	if n := s.Statuses[statusFailed]; n > 0 {
+		parts = append(parts, fmt.Sprintf("failed %d", n))
+	}
+
+	if len(parts) == 0 {
+		return "-"
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func (s Snapshot) eta() string {
+	eta, ok := s.ETA()
+	if !ok {
+		return "-"
+	}
+
+	return formatDuration(eta)
+}
+
+// formatBytes returns the size in the human-readable units.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
+
+// writer writes above the display in the terminal.
+type writer struct {
+	p *Progress
+	w io.Writer
+}
+
+// Write clears the display, writes to the underlying writer and redraws the display.
+func (w *writer) Write(b []byte) (int, error) {
+	w.p.mu.Lock()
+	defer w.p.mu.Unlock()
+
+	if w.p.stopped || w.p.lines == 0 {
+		return w.w.Write(b)
+	}
+
+	w.p.clear()
+	n, err := w.w.Write(b)
+	w.p.draw()
+
+	return n, err
+}
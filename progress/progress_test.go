@@ -0,0 +1,100 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/demyanovs/urlcrawler/queue"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgress_Snapshot(t *testing.T) {
+	p := New(&bytes.Buffer{}, false, func() queue.Stats {
+		return queue.Stats{Done: 4, ToDo: 10, InProgress: 2, Elapsed: 2 * time.Second}
+	})
+	p.Limit = 8
+
+	p.Page(parser.PageData{StatusCode: 200, Size: 1000})
+	p.Page(parser.PageData{StatusCode: 200, Size: 1048})
+	p.Page(parser.PageData{StatusCode: 404, Error: "returned status: 404"})
+	p.Page(parser.PageData{Error: "timeout"})
+
+	s := p.Snapshot()
+	require.Equal(t, 4, s.Pages)
+	require.Equal(t, 2, s.Failed)
+	require.Equal(t, map[int]int{2: 2, 4: 1, 0: 1}, s.Statuses)
+	require.Equal(t, int64(2048), s.Bytes)
+	require.Equal(t, 2.0, s.Rate())
+	require.Equal(t, 0.5, s.ErrorRate())
+
+	// 4 pages remaining up to the limit at 2 pages/s
+	eta, ok := s.ETA()
+	require.True(t, ok)
+	require.Equal(t, 2*time.Second, eta)
+
+	require.Equal(t,
+		"progress: done 4, queued 10, in flight 2, errors 50.0%, statuses 2xx 2, 4xx 1, failed 1, "+
+			"downloaded 2.0 KB, 2.0 pages/s, elapsed 2s, ETA 2s",
+		s.String(),
+	)
+}
+
+func TestSnapshot_ETAUnknown(t *testing.T) {
+	_, ok := Snapshot{Stats: queue.Stats{ToDo: 10}}.ETA()
+	require.False(t, ok)
+	require.Contains(t, Snapshot{}.String(), "statuses -")
+	require.Contains(t, Snapshot{}.String(), "ETA -")
+}
+
+func TestProgress_NonTerminal(t *testing.T) {
+	var out bytes.Buffer
+	p := New(&out, false, func() queue.Stats {
+		return queue.Stats{Done: 1}
+	})
+	p.Page(parser.PageData{StatusCode: 200})
+
+	require.Equal(t, &out, p.Writer(&out))
+
+	p.Start()
+	p.Stop()
+
+	require.Equal(t, p.Snapshot().String()+"\n", out.String())
+}
+
+func TestProgress_Terminal(t *testing.T) {
+	var out bytes.Buffer
+	p := New(&out, true, func() queue.Stats {
+		return queue.Stats{Done: 1, ToDo: 3}
+	})
+	p.Page(parser.PageData{StatusCode: 200})
+
+	p.mu.Lock()
+	p.draw()
+	p.mu.Unlock()
+
+	display := strings.Join(p.Snapshot().Lines(), "\n") + "\n"
+	require.Equal(t, display, out.String())
+
+	// The log line is written above the redrawn display
+	out.Reset()
+	w := p.Writer(&out)
+	_, err := w.Write([]byte("log line\n"))
+	require.NoError(t, err)
+	require.Equal(t, "\033[3A\r\033[J"+"log line\n"+display, out.String())
+
+	// The final display is kept on Stop and the writes are passed through
+	p.Stop()
+	out.Reset()
+	_, err = w.Write([]byte("after\n"))
+	require.NoError(t, err)
+	require.Equal(t, "after\n", out.String())
+}
+
+func TestFormatBytes(t *testing.T) {
+	require.Equal(t, "512 B", formatBytes(512))
+	require.Equal(t, "1.5 KB", formatBytes(1536))
+	require.Equal(t, "3.0 MB", formatBytes(3<<20))
+}
@@ -0,0 +1,63 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/demyanovs/urlcrawler/parser"
+)
+
+// ErrSkip is returned by the middlewares to skip the URL without recording it.
+var ErrSkip = errors.New("skip")
+
+// Request represents a request of the page with the context of the crawl.
+// The middlewares can modify the URL and the headers of the request.
+type Request struct {
+	URL      string
+	Header   http.Header
+	Depth    int
+	Referrer string
+	Source   string
+}
+
+// Handler represents a handler which fetches and parses the pages.
+type Handler interface {
+	Fetch(ctx context.Context, req *Request) (*http.Response, parser.Timing, error)
+	Parse(ctx context.Context, req *Request, resp *http.Response) (parser.PageData, []string, error)
+}
+
+// Middleware wraps the next handler to add behavior to fetching or parsing of the pages.
+// The middlewares can embed the next handler to wrap only one of its methods.
+type Middleware func(next Handler) Handler
+
+// Use adds the middlewares to the chain of the handlers. The first middleware is the outermost.
+// It must be called before Start.
+func (q *Queue) Use(middlewares ...Middleware) {
+	q.middlewares = append(q.middlewares, middlewares...)
+}
+
+// handler returns the chain of the middlewares around the fetcher and the parser of the queue.
+func (q *Queue) handler() Handler {
+	var h Handler = baseHandler{q: q}
+	for i := len(q.middlewares) - 1; i >= 0; i-- {
+		h = q.middlewares[i](h)
+	}
+
+	return h
+}
+
+// baseHandler represents the handler which fetches the pages with the fetcher and parses them with the parser.
+type baseHandler struct {
+	q *Queue
+}
+
+// Fetch fetches the page.
+func (h baseHandler) Fetch(ctx context.Context, req *Request) (*http.Response, parser.Timing, error) {
+	return h.q.fetcher.Fetch(ctx, req.URL, req.Header)
+}
+
+// Parse parses the response.
+func (h baseHandler) Parse(_ context.Context, _ *Request, resp *http.Response) (parser.PageData, []string, error) {
+	return h.q.Parser.ParseResponse(resp)
+}
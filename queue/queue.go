@@ -2,40 +2,114 @@ package queue
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/demyanovs/urlcrawler/fetcher"
 	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/demyanovs/urlcrawler/render"
+	"github.com/demyanovs/urlcrawler/state"
 	"github.com/demyanovs/urlcrawler/store"
-	"log"
+	"io"
+	"iter"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// Reasons of skipping the URLs passed to the OnSkip hook.
+const (
+	SkipRobots      = "robots"
+	SkipDepth       = "depth"
+	SkipNofollow    = "nofollow"
+	SkipLinkHook    = "link hook"
+	SkipRequestHook = "request hook"
+	SkipMiddleware  = "middleware"
+)
+
 // Queue represents a queue for processing URLs.
 type Queue struct {
 	Config          ConfigType
 	startURL        *url.URL
 	report          Reporter
 	RobotsData      RobotsData
-	parser          parser.Parser
-	logger          Logger
+	State           CrawlState
+	Parser          Parser
+	Hooks           Hooks
+	middlewares     []Middleware
+	chain           Handler
+	fetcher         *fetcher.Fetcher
+	logger          *slog.Logger
 	startedAt       time.Time
 	sURLsDone       URLStore
 	sURLsToDo       URLStore
 	sURLsInProgress URLStore
 	sURLsToSave     URLStore
+	sURLsSkipped    URLStore
+	sURLsScanned    URLStore
+	results         chan parser.PageData
+	saveMu          sync.Mutex
+	errMu           sync.Mutex
+	err             error
+	errors          atomic.Int64
+	skipped         atomic.Int64
+}
+
+// Hooks represents the callbacks of the crawling events.
+// The hooks are called concurrently by the workers, nil hooks are skipped.
+type Hooks struct {
+	// OnRequest is called before the request and can modify its headers.
+	// The URL is skipped if it returns an error.
+	OnRequest func(URL string, header http.Header) error
+	// OnResponse is called when the response is received, before it's parsed.
+	// It must not read the body of the response.
+	OnResponse func(resp *http.Response)
+	// OnPage is called when the page is processed.
+	OnPage func(page parser.PageData)
+	// OnLink is called for the links on the page before they're enqueued.
+	// The link isn't enqueued if it returns false.
+	OnLink func(from string, to string) bool
+	// OnError is called on the request and parse errors.
+	OnError func(URL string, err error)
+	// OnSkip is called when the URL is skipped with one of the Skip reasons.
+	OnSkip func(URL string, reason string)
+}
+
+// Stats represents the statistics of the crawl.
+type Stats struct {
+	Done       int
+	ToDo       int
+	InProgress int
+	Errors     int
+	Skipped    int
+	Elapsed    time.Duration
+}
+
+// task represents a URL waiting to be processed.
+// The script tasks are only scanned for the JS links of the referrer, they aren't pages.
+type task struct {
+	depth    int
+	referrer string
+	source   string
+	script   bool
 }
 
 // ConfigType represents a configuration for the queue.
 type ConfigType struct {
-	QueueLen   int
-	LimitURLs  int
-	BulkSize   int
-	ReqTimeout time.Duration
-	Delay      time.Duration
-	Depth      int
-	Quiet      bool
+	QueueLen             int
+	LimitURLs            int
+	BulkSize             int
+	ReqTimeout           time.Duration
+	Delay                time.Duration
+	Depth                int
+	BotName              string
+	RespectNofollowLinks bool
+	DiscoverJSLinks      bool
+	Extractor            parser.Extractor
+	Renderer             render.Renderer
 }
 
 // URLStore represents a store for URLs.
@@ -56,14 +130,24 @@ type RobotsData interface {
 	CrawlDelay(userAgent string) (*int, error)
 }
 
+// CrawlState represents a state of the previous crawl used for incremental recrawl.
+type CrawlState interface {
+	Previous(URL string) (state.Entry, bool)
+	Record(URL string, entry state.Entry) state.Change
+	Keep(URL string)
+	MarkIncomplete()
+}
+
+// Parser represents a parser of the responses.
+// It returns the data of the page and the links on the page to follow.
+type Parser interface {
+	ParseResponse(resp *http.Response) (parser.PageData, []string, error)
+}
+
 // Reporter represents a reporter.
 type Reporter interface {
 	SaveBulk(records []parser.PageData) error
-}
-
-// Logger represents a logger.
-type Logger interface {
-	Println(v ...any)
+	Close() error
 }
 
 // New creates a new queue.
@@ -71,7 +155,7 @@ func New(
 	config ConfigType,
 	startURL string,
 	report Reporter,
-	logger Logger,
+	logger *slog.Logger,
 	robotsData RobotsData,
 ) (*Queue, error) {
 	parsedURL, err := url.Parse(startURL)
@@ -79,123 +163,365 @@ func New(
 		return nil, err
 	}
 
+	// Nothing is logged without the logger
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+
+	p := parser.New()
+	p.Logger = logger
+	p.BotName = config.BotName
+	p.Extractor = config.Extractor
+	p.DiscoverJSLinks = config.DiscoverJSLinks
+
+	f := fetcher.New()
+	f.Renderer = config.Renderer
+
 	sURLsToDo := store.New()
-	sURLsToDo.Add(startURL, 0)
+	sURLsToDo.Add(startURL, task{})
 
 	return &Queue{
 		Config:          config,
 		startURL:        parsedURL,
 		report:          report,
 		RobotsData:      robotsData,
-		parser:          parser.New(),
+		Parser:          parser.NewMux(p),
+		fetcher:         f,
 		logger:          logger,
 		sURLsDone:       store.New(),
 		sURLsToDo:       sURLsToDo,
 		sURLsInProgress: store.New(),
 		sURLsToSave:     store.New(),
+		sURLsSkipped:    store.New(),
+		sURLsScanned:    store.New(),
 	}, nil
 }
 
-// Start starts the queue.
-func (q *Queue) Start() {
+// Start starts the queue and blocks until the crawl is completed or the context is done.
+// It returns the first error of saving the results or the error of the context.
+func (q *Queue) Start(ctx context.Context) error {
 	q.startedAt = time.Now()
+	q.chain = q.handler()
 	active := true
 	var wg sync.WaitGroup
 
-	queue := make(chan struct{}, q.Config.QueueLen)
+	// The IDs of the free workers, the number of the workers limits the parallel requests
+	workers := make(chan int, q.Config.QueueLen)
+	for i := 1; i <= q.Config.QueueLen; i++ {
+		workers <- i
+	}
 
 	for ok := true; ok; ok = active {
 		URLs := q.sURLsToDo.Keys()
-		wg.Add(len(URLs))
 
 		for w := 0; w < len(URLs); w++ {
 			if q.sURLsDone.Len() >= q.Config.LimitURLs && q.Config.LimitURLs > 0 {
-				q.log(fmt.Sprintf("reached max URLs limit of %d", q.Config.LimitURLs))
+				q.logger.Info("reached max URLs limit", "limit", q.Config.LimitURLs)
+				active = false
+				break
+			}
+
+			if ctx.Err() != nil || q.failed() {
 				active = false
-				q.Stop()
 				break
 			}
 
 			URL := URLs[w]
 			v, err := q.sURLsToDo.Get(URL)
 			if err != nil {
-				log.Fatalln(err)
+				q.setErr(fmt.Errorf("can't get url %s from the queue: %s", URL, err))
+				active = false
+				break
 			}
-			q.process(queue, &wg, URL, v.(int))
 
-			time.Sleep(q.Config.Delay)
+			wg.Add(1)
+			q.process(ctx, workers, &wg, URL, v.(task))
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(q.Config.Delay):
+			}
 		}
 
-		if q.sURLsToDo.Len() == 0 && q.sURLsInProgress.Len() == 0 {
-			q.Stop()
+		// The workers add the links before leaving the in progress, so it's checked first
+		if !active || (q.sURLsInProgress.Len() == 0 && q.sURLsToDo.Len() == 0) {
+			// Wait for the URLs in progress before saving the results
+			wg.Wait()
+
+			// The URLs which weren't reached aren't removed from the state
+			if q.State != nil && (!active || ctx.Err() != nil) {
+				q.State.MarkIncomplete()
+			}
+
+			q.setErr(q.Stop())
 			active = false
 		}
 	}
+
+	if q.results != nil {
+		close(q.results)
+	}
+
+	q.errMu.Lock()
+	defer q.errMu.Unlock()
+	if q.err != nil {
+		return q.err
+	}
+
+	return ctx.Err()
 }
 
-// Stop stops the queue.
-func (q *Queue) Stop() {
+// Results returns the channel of the pages sent as they're processed.
+// The workers wait for the pages to be received, so the channel should be read until it's closed
+// on completion or cancellation of the crawl. It must be called before Start.
+func (q *Queue) Results() <-chan parser.PageData {
+	if q.results == nil {
+		q.results = make(chan parser.PageData)
+	}
+
+	return q.results
+}
+
+// Stream starts the queue and returns the iterator of the pages as they're processed.
+// The error which stopped the queue, if any, is yielded last. Breaking the loop stops the queue.
+func (q *Queue) Stream(ctx context.Context) iter.Seq2[parser.PageData, error] {
+	return func(yield func(parser.PageData, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		results := q.Results()
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- q.Start(ctx)
+		}()
+
+		for page := range results {
+			if !yield(page, nil) {
+				cancel()
+				for range results {
+				}
+				<-errCh
+				return
+			}
+		}
+
+		if err := <-errCh; err != nil {
+			yield(parser.PageData{}, err)
+		}
+	}
+}
+
+// Pages returns the data of all the processed pages.
+func (q *Queue) Pages() parser.PagesData {
+	return q.toPagesData(q.sURLsDone.Values())
+}
+
+// Stats returns the statistics of the crawl.
+func (q *Queue) Stats() Stats {
+	var elapsed time.Duration
+	if !q.startedAt.IsZero() {
+		elapsed = time.Since(q.startedAt)
+	}
+
+	return Stats{
+		Done:       q.sURLsDone.Len(),
+		ToDo:       q.sURLsToDo.Len(),
+		InProgress: q.sURLsInProgress.Len(),
+		Errors:     int(q.errors.Load()),
+		Skipped:    int(q.skipped.Load()),
+		Elapsed:    elapsed,
+	}
+}
+
+// Stop saves the remaining results and closes the report.
+func (q *Queue) Stop() error {
 	err := q.saveResults()
 	if err != nil {
-		log.Println(err)
-		return
+		return err
 	}
 
-	elapsed := time.Since(q.startedAt)
-	q.log(fmt.Sprintf("crawling completed. %d of %d URLs processed in %s", q.sURLsDone.Len(), q.sURLsToDo.Len(), elapsed.Round(time.Second)))
+	if q.report != nil {
+		err = q.report.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	q.logger.Info(
+		"crawling completed",
+		"done", q.sURLsDone.Len(),
+		"todo", q.sURLsToDo.Len(),
+		"duration", time.Since(q.startedAt).Round(time.Second),
+	)
+
+	return nil
 }
 
-func (q *Queue) process(queue chan struct{}, wg *sync.WaitGroup, URL string, depth int) {
-	queue <- struct{}{}
+func (q *Queue) process(ctx context.Context, workers chan int, wg *sync.WaitGroup, URL string, t task) {
+	depth := t.depth
+
+	// Move the URL to the in progress before the next pass of the queue can pick it again
+	q.sURLsToDo.Delete(URL)
+	q.sURLsInProgress.Add(URL, depth)
+
+	worker := <-workers
 
 	go func() {
 		defer wg.Done()
+		defer func() { workers <- worker }()
 
-		q.sURLsToDo.Delete(URL)
-		q.sURLsInProgress.Add(URL, depth)
-
-		q.log(fmt.Sprintf("processing: %s (found: %d)", URL, q.sURLsToDo.Len()))
+		logger := q.logger.With("url", URL, "depth", depth, "worker", worker)
+		logger.Debug("processing", "todo", q.sURLsToDo.Len())
 
-		ctx, cancel := context.WithTimeout(context.Background(), q.Config.ReqTimeout)
+		reqCtx, cancel := context.WithTimeout(ctx, q.Config.ReqTimeout)
 		defer cancel()
 
+		if t.script {
+			q.scanScript(reqCtx, logger, URL, t)
+			return
+		}
+
 		var pageData parser.PageData
 		var linksOnPage []string
+		var prev state.Entry
+		var hasPrev bool
+		header := http.Header{}
+
+		if q.State != nil {
+			prev, hasPrev = q.State.Previous(URL)
+			if hasPrev && prev.ETag != "" {
+				header.Set("If-None-Match", prev.ETag)
+			}
+			if hasPrev && prev.LastModified != "" {
+				header.Set("If-Modified-Since", prev.LastModified)
+			}
+		}
+
+		if q.Hooks.OnRequest != nil {
+			err := q.Hooks.OnRequest(URL, header)
+			if err != nil {
+				q.skipProcessed(URL, SkipRequestHook)
+				return
+			}
+		}
+
+		req := &Request{
+			URL:      URL,
+			Header:   header,
+			Depth:    depth,
+			Referrer: t.referrer,
+			Source:   t.source,
+		}
 
 		// Start processing
-		resp, err := q.readURL(ctx, URL)
-		if err != nil {
+		entry := state.Entry{}
+		resp, timing, err := q.chain.Fetch(reqCtx, req)
+		if errors.Is(err, ErrSkip) {
+			q.skipProcessed(URL, SkipMiddleware)
+			return
+		}
+
+		if err == nil && q.Hooks.OnResponse != nil {
+			q.Hooks.OnResponse(resp)
+		}
+
+		fetchFailed := err != nil
+		if fetchFailed {
 			pageData = parser.PageData{
-				URL: URL,
+				URL:   URL,
+				Error: err.Error(),
+			}
+			q.error(logger, URL, "request failed", err)
+		} else if resp.StatusCode == http.StatusNotModified && hasPrev {
+			resp.Body.Close()
+			entry = prev
+			if etag := resp.Header.Get("ETag"); etag != "" {
+				entry.ETag = etag
 			}
-			fmt.Println(fmt.Errorf("can't send request to url %s. Error: %s", URL, err))
+			pageData, linksOnPage = prev.Page, prev.Links
 		} else {
-			pageData, linksOnPage, err = q.parser.ParseResponse(resp)
+			entry.ETag = resp.Header.Get("ETag")
+			entry.LastModified = resp.Header.Get("Last-Modified")
+			pageData, linksOnPage, err = q.chain.Parse(reqCtx, req, resp)
+			if errors.Is(err, ErrSkip) {
+				q.skipProcessed(URL, SkipMiddleware)
+				return
+			}
 			if err != nil {
-				log.Println(err)
+				pageData.Error = err.Error()
+				q.error(logger.With("status", resp.StatusCode), URL, "page error", err)
 			}
 		}
 
+		if resp != nil {
+			pageData.TLS = fetcher.TLS(resp.TLS)
+		}
+
+		pageData.Depth = depth
+		pageData.Referrer = t.referrer
+		pageData.Source = t.source
+		pageData.Timing = timing
+		pageData.ResponseTime = timing.Total.Milliseconds()
+		pageData.Links = nil
+		for _, l := range linksOnPage {
+			pageData.Links = append(pageData.Links, q.fullURL(l))
+		}
+
+		if q.State != nil && fetchFailed {
+			// The previous entry is kept, so the page isn't changed or removed because of a transient error
+			q.State.Keep(URL)
+		} else if q.State != nil {
+			entry.Page, entry.Links = pageData, linksOnPage
+			pageData.Change = string(q.State.Record(URL, entry))
+		}
+
 		q.sURLsDone.Add(URL, pageData)
 		q.sURLsToSave.Add(URL, pageData)
 
-		if len(linksOnPage) > 0 && (q.Config.Depth == 0 || depth <= q.Config.Depth) {
-			q.addSURLsToDo(linksOnPage, depth)
+		if pageData.Error == "" {
+			logger.Info("page crawled", "status", pageData.StatusCode, "duration", timing.Total, "size", pageData.Size)
+		}
+
+		if q.Hooks.OnPage != nil {
+			q.Hooks.OnPage(pageData)
+		}
+
+		if q.results != nil {
+			select {
+			case q.results <- pageData:
+			case <-ctx.Done():
+			}
+		}
+
+		// Do not follow links from the page if it's disallowed by meta robots or X-Robots-Tag
+		if pageData.NoFollow {
+			logger.Debug("nofollow page, links are not followed")
+			for _, l := range linksOnPage {
+				q.skip(q.fullURL(l), SkipNofollow)
+			}
+		} else if len(linksOnPage) > 0 && (q.Config.Depth == 0 || depth <= q.Config.Depth) {
+			q.addSURLsToDo(q.followLinks(linksOnPage, pageData.NofollowLinks), depth, URL, "")
+		}
+
+		if !pageData.NoFollow && len(pageData.JSLinks) > 0 && (q.Config.Depth == 0 || depth <= q.Config.Depth) {
+			q.addJSLinksToDo(pageData.JSLinks, depth, URL)
+		}
+
+		if !pageData.NoFollow && len(pageData.Scripts) > 0 && (q.Config.Depth == 0 || depth <= q.Config.Depth) {
+			q.addScriptsToDo(pageData.Scripts, depth, URL)
 		}
 
 		q.sURLsInProgress.Delete(URL)
 
 		if q.sURLsToSave.Len() >= q.Config.BulkSize {
-			q.log(fmt.Sprintf("store is full: %d", q.sURLsToSave.Len()))
+			logger.Debug("store is full", "records", q.sURLsToSave.Len())
 
 			err = q.saveResults()
 			if err != nil {
-				log.Println(err)
+				q.setErr(err)
 				return
 			}
 		}
-
-		<-queue
 	}()
 }
 
@@ -212,7 +538,9 @@ func (q *Queue) toPagesData(data []any) parser.PagesData {
 }
 
 func (q *Queue) saveResults() error {
-	q.log("saving to the file...")
+	q.saveMu.Lock()
+	defer q.saveMu.Unlock()
+
 	if q.report == nil || q.sURLsToSave.Len() == 0 {
 		return nil
 	}
@@ -226,52 +554,207 @@ func (q *Queue) saveResults() error {
 
 	q.sURLsToSave.Clear()
 
-	q.log(fmt.Sprintf("saved. Done %d, todo %d", q.sURLsDone.Len(), q.sURLsToDo.Len()))
+	q.logger.Debug("results saved", "records", len(pagesData), "done", q.sURLsDone.Len(), "todo", q.sURLsToDo.Len())
 
 	return nil
 }
 
-func (q *Queue) addSURLsToDo(linksOnPage []string, depth int) {
+func (q *Queue) addSURLsToDo(linksOnPage []string, depth int, referrer string, source string) {
 	for _, l := range linksOnPage {
+		fullURL := q.fullURL(l)
+		if _, err := q.sURLsDone.Get(fullURL); err == nil {
+			continue
+		}
+		if _, err := q.sURLsSkipped.Get(fullURL); err == nil {
+			continue
+		}
+		if _, err := q.sURLsInProgress.Get(fullURL); err == nil {
+			continue
+		}
+		if _, err := q.sURLsScanned.Get(fullURL); err == nil {
+			continue
+		}
+
 		// Check if the URL is allowed in robots.txt
 		isAllowed := q.RobotsData == nil || q.RobotsData.IsAllowed("*", l)
 		if isAllowed == false {
+			q.skip(fullURL, SkipRobots)
 			continue
 		}
 
-		fullURL := fmt.Sprintf("%s://%s/%s", q.startURL.Scheme, q.startURL.Host, l)
-		_, err := q.sURLsDone.Get(fullURL)
-		if err != nil {
-			// Do not add the URL if depth is greater than the limit
-			nextDepth := depth + 1
-			if q.Config.Depth > 0 && nextDepth > q.Config.Depth {
-				continue
-			}
+		// Do not add the URL if depth is greater than the limit
+		nextDepth := depth + 1
+		if q.Config.Depth > 0 && nextDepth > q.Config.Depth {
+			q.skip(fullURL, SkipDepth)
+			continue
+		}
+
+		if q.Hooks.OnLink != nil && !q.Hooks.OnLink(referrer, fullURL) {
+			q.skip(fullURL, SkipLinkHook)
+			continue
+		}
+
+		q.sURLsToDo.Add(fullURL, task{depth: nextDepth, referrer: referrer, source: source})
+	}
+}
+
+// addJSLinksToDo adds the links found by the JavaScript link discovery
+// unless the URLs are already known from the HTML links.
+func (q *Queue) addJSLinksToDo(links []string, depth int, referrer string) {
+	var unknown []string
+	for _, l := range links {
+		fullURL := q.fullURL(l)
+		if _, err := q.sURLsToDo.Get(fullURL); err == nil {
+			continue
+		}
+
+		unknown = append(unknown, l)
+	}
+
+	q.addSURLsToDo(unknown, depth, referrer, parser.SourceJSDiscovered)
+}
+
+// addScriptsToDo adds the same-origin scripts of the page to scan them for the JS links.
+// The scripts get the depth of the page, so the links found in them are one level deeper than the page.
+func (q *Queue) addScriptsToDo(scripts []string, depth int, referrer string) {
+	for _, s := range scripts {
+		fullURL := q.fullURL(s)
+		if _, err := q.sURLsScanned.Get(fullURL); err == nil {
+			continue
+		}
+		if _, err := q.sURLsToDo.Get(fullURL); err == nil {
+			continue
+		}
+		if _, err := q.sURLsInProgress.Get(fullURL); err == nil {
+			continue
+		}
 
-			q.sURLsToDo.Add(fullURL, nextDepth)
+		if q.RobotsData != nil && !q.RobotsData.IsAllowed("*", s) {
+			q.skip(fullURL, SkipRobots)
+			continue
 		}
+
+		q.sURLsToDo.Add(fullURL, task{depth: depth, referrer: referrer, source: parser.SourceJSDiscovered, script: true})
 	}
 }
 
-func (q *Queue) readURL(ctx context.Context, url string) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// scanScript fetches and parses the script and adds its JS links as found on the referrer.
+// The script isn't recorded as a page, so it's not reported and doesn't count towards the limit.
+func (q *Queue) scanScript(ctx context.Context, logger *slog.Logger, URL string, t task) {
+	defer q.sURLsInProgress.Delete(URL)
+	q.sURLsScanned.Add(URL, t.depth)
+
+	header := http.Header{}
+	if q.Hooks.OnRequest != nil && q.Hooks.OnRequest(URL, header) != nil {
+		q.skip(URL, SkipRequestHook)
+		return
+	}
+
+	req := &Request{
+		URL:      URL,
+		Header:   header,
+		Depth:    t.depth,
+		Referrer: t.referrer,
+		Source:   t.source,
+	}
+
+	resp, _, err := q.chain.Fetch(ctx, req)
+	if errors.Is(err, ErrSkip) {
+		q.skip(URL, SkipMiddleware)
+		return
+	}
 	if err != nil {
-		return nil, err
+		logger.Warn("script scan failed", "error", err)
+		return
 	}
 
-	client := http.Client{}
-	resp, err := client.Do(req)
+	pageData, _, err := q.chain.Parse(ctx, req, resp)
+	if errors.Is(err, ErrSkip) {
+		q.skip(URL, SkipMiddleware)
+		return
+	}
 	if err != nil {
-		return nil, err
+		logger.Warn("script scan failed", "status", resp.StatusCode, "error", err)
+		return
+	}
+
+	logger.Debug("script scanned", "js_links", len(pageData.JSLinks))
+
+	if len(pageData.JSLinks) > 0 {
+		q.addJSLinksToDo(pageData.JSLinks, t.depth, t.referrer)
+	}
+}
+
+// followLinks returns the links to follow, skipping rel="nofollow" links if configured.
+func (q *Queue) followLinks(links []string, nofollowLinks []string) []string {
+	if !q.Config.RespectNofollowLinks || len(nofollowLinks) == 0 {
+		return links
 	}
 
-	return resp, nil
+	var follow []string
+	for _, l := range links {
+		if slices.Contains(nofollowLinks, l) {
+			q.skip(q.fullURL(l), SkipNofollow)
+			continue
+		}
+
+		follow = append(follow, l)
+	}
+
+	return follow
+}
+
+func (q *Queue) fullURL(link string) string {
+	return fmt.Sprintf("%s://%s/%s", q.startURL.Scheme, q.startURL.Host, link)
+}
+
+// error counts the error of the URL, passes it to the OnError hook and logs it with the message.
+func (q *Queue) error(logger *slog.Logger, URL string, message string, err error) {
+	q.errors.Add(1)
+
+	if q.Hooks.OnError != nil {
+		q.Hooks.OnError(URL, err)
+	}
+
+	logger.Error(message, "error", err)
 }
 
-func (q *Queue) log(message string) {
-	if q.Config.Quiet == true {
+// skip counts the skipped URL and passes it to the OnSkip hook.
+func (q *Queue) skip(URL string, reason string) {
+	q.skipped.Add(1)
+	q.logger.Debug("skipped", "url", URL, "reason", reason)
+
+	if q.Hooks.OnSkip != nil {
+		q.Hooks.OnSkip(URL, reason)
+	}
+}
+
+// skipProcessed skips the URL picked for processing and remembers it,
+// so it isn't enqueued again by the links from the other pages.
+func (q *Queue) skipProcessed(URL string, reason string) {
+	q.sURLsSkipped.Add(URL, reason)
+	q.sURLsInProgress.Delete(URL)
+	q.skip(URL, reason)
+}
+
+// setErr records the first error which stops the queue.
+func (q *Queue) setErr(err error) {
+	if err == nil {
 		return
 	}
 
-	q.logger.Println(message)
+	q.errMu.Lock()
+	defer q.errMu.Unlock()
+
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+// failed reports whether the queue is stopped by an error.
+func (q *Queue) failed() bool {
+	q.errMu.Lock()
+	defer q.errMu.Unlock()
+
+	return q.err != nil
 }
@@ -0,0 +1,59 @@
+package render
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// Fake represents a renderer which returns the predefined pages by URL, for tests.
+// Unknown URLs are rendered as 404 pages. Requests records the received requests.
+type Fake struct {
+	Pages    map[string]Response
+	mu       sync.Mutex
+	Requests []Request
+}
+
+// NewFake creates a new Fake with the pages.
+func NewFake(pages map[string]Response) *Fake {
+	return &Fake{
+		Pages: pages,
+	}
+}
+
+// Render returns the predefined page.
+func (f *Fake) Render(ctx context.Context, req Request) (Response, error) {
+	resp := f.Serve(ctx, req)
+	if resp.Error != "" {
+		return resp, errors.New(resp.Error)
+	}
+
+	return resp, nil
+}
+
+// Serve returns the predefined page, so the fake can be served with ServeProcess or Handler.
+func (f *Fake) Serve(_ context.Context, req Request) Response {
+	f.mu.Lock()
+	f.Requests = append(f.Requests, req)
+	f.mu.Unlock()
+
+	resp, ok := f.Pages[req.URL]
+	if !ok {
+		return Response{
+			URL:     req.URL,
+			Status:  http.StatusNotFound,
+			Headers: map[string][]string{"Content-Type": {"text/html"}},
+			HTML:    "<html><head><title>Not Found</title></head></html>",
+		}
+	}
+
+	if resp.URL == "" {
+		resp.URL = req.URL
+	}
+	if resp.Status == 0 && resp.Error == "" {
+		resp.Status = http.StatusOK
+	}
+
+	return resp
+}
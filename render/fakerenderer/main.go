@@ -0,0 +1,74 @@
+// Command fakerenderer is a fake external renderer for tests and as an example of the renderer protocol.
+// It fetches the pages without executing JavaScript and returns them as rendered.
+//
+// Usage as a process renderer:
+//
+//	urlcrawler -u=https://example.com -render-cmd="fakerenderer"
+//
+// Usage as an HTTP renderer:
+//
+//	fakerenderer -http=127.0.0.1:9222
+//	urlcrawler -u=https://example.com -render-url=http://127.0.0.1:9222/
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/demyanovs/urlcrawler/render"
+)
+
+func main() {
+	addr := flag.String("http", "", "Address to serve the HTTP renderer protocol on (stdin and stdout are used if empty)")
+	flag.Parse()
+
+	if *addr != "" {
+		log.Fatal(http.ListenAndServe(*addr, render.Handler(fetch)))
+	}
+
+	err := render.ServeProcess(context.Background(), os.Stdin, os.Stdout, fetch)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// fetch fetches the page with the request headers.
+func fetch(ctx context.Context, req render.Request) render.Response {
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(req.Timeout)*time.Millisecond)
+		defer cancel()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, req.URL, nil)
+	if err != nil {
+		return render.Response{Error: err.Error()}
+	}
+
+	for k, v := range req.Headers {
+		httpReq.Header[k] = v
+	}
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return render.Response{Error: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return render.Response{Error: err.Error()}
+	}
+
+	return render.Response{
+		URL:     resp.Request.URL.String(),
+		Status:  resp.StatusCode,
+		Headers: resp.Header,
+		HTML:    string(body),
+	}
+}
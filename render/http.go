@@ -0,0 +1,60 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// HTTPRenderer represents a renderer running as a local HTTP server.
+type HTTPRenderer struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewHTTPRenderer creates a new HTTPRenderer with the URL of the renderer endpoint.
+func NewHTTPRenderer(URL string) *HTTPRenderer {
+	return &HTTPRenderer{
+		URL:    URL,
+		Client: &http.Client{},
+	}
+}
+
+// Render sends the request to the renderer endpoint and returns the response.
+func (r *HTTPRenderer) Render(ctx context.Context, req Request) (Response, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return Response{}, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(data))
+	if err != nil {
+		return Response{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := r.Client.Do(httpReq)
+	if err != nil {
+		return Response{}, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return Response{}, fmt.Errorf("renderer returned status: %s", httpResp.Status)
+	}
+
+	var resp Response
+	err = json.NewDecoder(httpResp.Body).Decode(&resp)
+	if err != nil {
+		return Response{}, fmt.Errorf("invalid renderer response: %s", err)
+	}
+
+	if resp.Error != "" {
+		return resp, errors.New(resp.Error)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,146 @@
+package render
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// maxLineSize limits the size of the line of the process renderer protocol.
+const maxLineSize = 64 << 20
+
+// ErrProcessExited is returned if the renderer process has exited.
+var ErrProcessExited = errors.New("renderer process exited")
+
+// ProcessRenderer represents a renderer running as an external process
+// speaking the protocol over stdin and stdout.
+type ProcessRenderer struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]chan Response
+	done    chan struct{}
+	err     error
+}
+
+// NewProcessRenderer starts the renderer process.
+// Stderr of the process is forwarded to stderr of the crawler.
+func NewProcessRenderer(name string, args ...string) (*ProcessRenderer, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &ProcessRenderer{
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[int]chan Response),
+		done:    make(chan struct{}),
+	}
+
+	go r.read(stdout)
+
+	return r, nil
+}
+
+// Render sends the request to the process and waits for the response.
+func (r *ProcessRenderer) Render(ctx context.Context, req Request) (Response, error) {
+	ch := make(chan Response, 1)
+
+	r.mu.Lock()
+	if r.err != nil {
+		r.mu.Unlock()
+		return Response{}, r.err
+	}
+
+	r.nextID++
+	req.ID = r.nextID
+	r.pending[req.ID] = ch
+
+	data, err := json.Marshal(req)
+	if err == nil {
+		_, err = r.stdin.Write(append(data, '\n'))
+	}
+	if err != nil {
+		delete(r.pending, req.ID)
+		r.mu.Unlock()
+		return Response{}, err
+	}
+	r.mu.Unlock()
+
+	select {
+	case resp := <-ch:
+		if resp.Error != "" {
+			return resp, errors.New(resp.Error)
+		}
+		return resp, nil
+	case <-r.done:
+		return Response{}, r.err
+	case <-ctx.Done():
+		r.mu.Lock()
+		delete(r.pending, req.ID)
+		r.mu.Unlock()
+		return Response{}, ctx.Err()
+	}
+}
+
+// Close closes stdin of the process and waits for it to exit.
+func (r *ProcessRenderer) Close() error {
+	err := r.stdin.Close()
+	if err != nil {
+		return err
+	}
+
+	<-r.done
+
+	return r.cmd.Wait()
+}
+
+func (r *ProcessRenderer) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		var resp Response
+		err := json.Unmarshal(scanner.Bytes(), &resp)
+		if err != nil {
+			continue
+		}
+
+		r.mu.Lock()
+		ch, ok := r.pending[resp.ID]
+		delete(r.pending, resp.ID)
+		r.mu.Unlock()
+
+		if ok {
+			ch <- resp
+		}
+	}
+
+	r.mu.Lock()
+	r.err = ErrProcessExited
+	if err := scanner.Err(); err != nil {
+		r.err = err
+	}
+	r.mu.Unlock()
+
+	close(r.done)
+}
@@ -0,0 +1,118 @@
+// Package render implements the protocol of the external renderers which render the pages,
+// e.g. with a headless browser, on behalf of the crawler.
+//
+// The crawler sends a JSON request with the URL and the request headers:
+//
+//	{"id": 1, "url": "https://example.com/", "headers": {"If-None-Match": ["\"v1\""]}, "timeout": 5000}
+//
+// and the renderer replies with a JSON response with the final URL after redirects,
+// the status code, the response headers and the rendered HTML:
+//
+//	{"id": 1, "url": "https://example.com/", "status": 200, "headers": {"Content-Type": ["text/html"]}, "html": "<html>...</html>"}
+//
+// or with the error if the page can't be rendered:
+//
+//	{"id": 1, "error": "navigation timeout"}
+//
+// A process renderer reads the requests from stdin and writes the responses to stdout,
+// one JSON object per line. The requests can be sent before the previous responses are written,
+// so the responses are matched to the requests by the id.
+//
+// An HTTP renderer accepts the request in the body of a POST request and replies with the response in the body.
+package render
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Request represents a request to render the page.
+// Timeout is in milliseconds.
+type Request struct {
+	ID      int                 `json:"id,omitempty"`
+	URL     string              `json:"url"`
+	Headers map[string][]string `json:"headers,omitempty"`
+	Timeout int64               `json:"timeout,omitempty"`
+}
+
+// Response represents the rendered page.
+type Response struct {
+	ID      int                 `json:"id,omitempty"`
+	URL     string              `json:"url,omitempty"`
+	Status  int                 `json:"status,omitempty"`
+	Headers map[string][]string `json:"headers,omitempty"`
+	HTML    string              `json:"html,omitempty"`
+	Error   string              `json:"error,omitempty"`
+}
+
+// Renderer represents a renderer of the pages.
+type Renderer interface {
+	Render(ctx context.Context, req Request) (Response, error)
+}
+
+// Func represents a function rendering the page on the renderer side.
+type Func func(ctx context.Context, req Request) Response
+
+// ServeProcess serves the process renderer protocol: reads the requests from in and writes the responses to out.
+// The requests are rendered concurrently.
+func ServeProcess(ctx context.Context, in io.Reader, out io.Writer, fn Func) error {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	encoder := json.NewEncoder(out)
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		var req Request
+		err := json.Unmarshal(scanner.Bytes(), &req)
+		if err != nil {
+			mu.Lock()
+			_ = encoder.Encode(Response{Error: err.Error()})
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			resp := fn(ctx, req)
+			resp.ID = req.ID
+
+			mu.Lock()
+			defer mu.Unlock()
+			_ = encoder.Encode(resp)
+		}()
+	}
+
+	wg.Wait()
+
+	return scanner.Err()
+}
+
+// Handler returns the HTTP handler serving the HTTP renderer protocol.
+func Handler(fn Func) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req Request
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		resp := fn(r.Context(), req)
+		resp.ID = req.ID
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
@@ -0,0 +1,96 @@
+package render
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakePages are served by the test binary running as the renderer process.
+var fakePages = map[string]Response{
+	"https://example.com/": {
+		Headers: map[string][]string{"Content-Type": {"text/html"}},
+		HTML:    "<html><head><title>Rendered</title></head></html>",
+	},
+	"https://example.com/error": {
+		Error: "navigation timeout",
+	},
+}
+
+func TestMain(m *testing.M) {
+	if os.Getenv("RENDER_HELPER_PROCESS") == "1" {
+		err := ServeProcess(context.Background(), os.Stdin, os.Stdout, NewFake(fakePages).Serve)
+		if err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestProcessRenderer_Success(t *testing.T) {
+	t.Setenv("RENDER_HELPER_PROCESS", "1")
+
+	r, err := NewProcessRenderer(os.Args[0])
+	require.NoError(t, err)
+
+	resp, err := r.Render(context.Background(), Request{URL: "https://example.com/"})
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/", resp.URL)
+	require.Equal(t, http.StatusOK, resp.Status)
+	require.Equal(t, "<html><head><title>Rendered</title></head></html>", resp.HTML)
+
+	resp, err = r.Render(context.Background(), Request{URL: "https://example.com/missing"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, resp.Status)
+
+	_, err = r.Render(context.Background(), Request{URL: "https://example.com/error"})
+	require.EqualError(t, err, "navigation timeout")
+
+	require.NoError(t, r.Close())
+
+	_, err = r.Render(context.Background(), Request{URL: "https://example.com/"})
+	require.ErrorIs(t, err, ErrProcessExited)
+}
+
+func TestProcessRenderer_ExitedError(t *testing.T) {
+	r, err := NewProcessRenderer("true")
+	require.NoError(t, err)
+
+	_, err = r.Render(context.Background(), Request{URL: "https://example.com/"})
+	require.Error(t, err)
+}
+
+func TestHTTPRenderer_Success(t *testing.T) {
+	fake := NewFake(fakePages)
+	server := httptest.NewServer(Handler(fake.Serve))
+	defer server.Close()
+
+	r := NewHTTPRenderer(server.URL)
+	resp, err := r.Render(context.Background(), Request{
+		URL:     "https://example.com/",
+		Headers: map[string][]string{"If-None-Match": {`"v1"`}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Status)
+	require.Equal(t, []string{"text/html"}, resp.Headers["Content-Type"])
+
+	require.Len(t, fake.Requests, 1)
+	require.Equal(t, []string{`"v1"`}, fake.Requests[0].Headers["If-None-Match"])
+
+	_, err = r.Render(context.Background(), Request{URL: "https://example.com/error"})
+	require.EqualError(t, err, "navigation timeout")
+}
+
+func TestHTTPRenderer_StatusError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := NewHTTPRenderer(server.URL).Render(context.Background(), Request{URL: "https://example.com/"})
+	require.EqualError(t, err, "renderer returned status: 404 Not Found")
+}
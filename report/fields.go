@@ -0,0 +1,429 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/demyanovs/urlcrawler/parser"
+)
+
+// Field represents a report field.
+// Name is used to select the field, Header is the CSV column
+// and Key is the key of the field in JSON reports.
+// Fields without the setter are skipped when reading reports.
+type Field struct {
+	Name   string
+	Header string
+	Key    string
+	value  func(p parser.PageData) string
+	get    func(p parser.PageData) any
+	set    func(p *parser.PageData, value string) error
+}
+
+// Value returns the field value of the page formatted for the report.
+func (f Field) Value(p parser.PageData) string {
+	return f.value(p)
+}
+
+// Fields represents a registry of report fields in the default order.
+var Fields = []Field{
+	stringField("url", "URL", "path", func(p *parser.PageData) *string { return &p.URL }),
+	intField("status_code", "StatusCode", "status code", func(p *parser.PageData) *int { return &p.StatusCode }),
+	stringField("title", "Title", "title", func(p *parser.PageData) *string { return &p.Title }),
+	stringField("description", "Description", "desc", func(p *parser.PageData) *string { return &p.Desc }),
+	stringField("keywords", "Keywords", "keywords", func(p *parser.PageData) *string { return &p.Keywords }),
+	intField("depth", "Depth", "depth", func(p *parser.PageData) *int { return &p.Depth }),
+	stringField("content_type", "ContentType", "content type", func(p *parser.PageData) *string { return &p.ContentType }),
+	{
+		Name:   "response_time",
+		Header: "ResponseTime",
+		Key:    "response time",
+		value: func(p parser.PageData) string {
+			return strconv.FormatInt(p.ResponseTime, 10)
+		},
+		get: func(p parser.PageData) any {
+			return p.ResponseTime
+		},
+		set: func(p *parser.PageData, value string) error {
+			var err error
+			p.ResponseTime, err = parseInt64(value)
+			return err
+		},
+	},
+	intField("size", "Size", "size", func(p *parser.PageData) *int { return &p.Size }),
+	stringField("canonical", "Canonical", "canonical", func(p *parser.PageData) *string { return &p.Canonical }),
+	{
+		Name:   "h1",
+		Header: "H1",
+		Key:    "h1",
+		value: func(p parser.PageData) string {
+			if len(p.H1) == 0 {
+				return ""
+			}
+			return p.H1[0]
+		},
+		get: func(p parser.PageData) any {
+			return p.H1
+		},
+		set: func(p *parser.PageData, value string) error {
+			if value != "" {
+				p.H1 = []string{value}
+			}
+			return nil
+		},
+	},
+	intField("word_count", "WordCount", "word count", func(p *parser.PageData) *int { return &p.WordCount }),
+	stringField("lang", "Lang", "lang", func(p *parser.PageData) *string { return &p.Lang }),
+	stringField("robots", "Robots", "robots", func(p *parser.PageData) *string { return &p.Robots }),
+	boolField("noindex", "NoIndex", "noindex", func(p *parser.PageData) *bool { return &p.NoIndex }),
+	boolField("nofollow", "NoFollow", "nofollow", func(p *parser.PageData) *bool { return &p.NoFollow }),
+	intField("images_without_alt", "ImagesWithoutAlt", "images without alt", func(p *parser.PageData) *int { return &p.ImagesNoAlt }),
+	{
+		Name:   "assets",
+		Header: "Assets",
+		Key:    "assets count",
+		value: func(p parser.PageData) string {
+			return strconv.Itoa(len(p.Assets))
+		},
+		get: func(p parser.PageData) any {
+			return len(p.Assets)
+		},
+	},
+	openGraphField("og_title", "OGTitle", "og:title"),
+	openGraphField("og_image", "OGImage", "og:image"),
+	{
+		Name:   "schema_types",
+		Header: "SchemaTypes",
+		Key:    "schema types",
+		value: func(p parser.PageData) string {
+			return strings.Join(p.Structured.SchemaTypes(), ", ")
+		},
+		get: func(p parser.PageData) any {
+			return p.Structured.SchemaTypes()
+		},
+	},
+	stringField("referrer", "Referrer", "referrer", func(p *parser.PageData) *string { return &p.Referrer }),
+	stringField("source", "Source", "source", func(p *parser.PageData) *string { return &p.Source }),
+	{
+		Name:   "js_links",
+		Header: "JSLinks",
+		Key:    "js links count",
+		value: func(p parser.PageData) string {
+			return strconv.Itoa(len(p.JSLinks))
+		},
+		get: func(p parser.PageData) any {
+			return len(p.JSLinks)
+		},
+	},
+	tlsField("tls_version", "TLSVersion", "tls version", func(t *parser.TLS) string { return t.Version }),
+	tlsField("cert_issuer", "CertIssuer", "certificate issuer", func(t *parser.TLS) string { return t.Issuer }),
+	tlsField("cert_expiry", "CertExpiry", "certificate expiry", func(t *parser.TLS) string { return t.NotAfter.Format(time.DateOnly) }),
+	durationField("dns_time", "DNSTime", "dns time", func(p *parser.PageData) *time.Duration { return &p.Timing.DNS }),
+	durationField("connect_time", "ConnectTime", "connect time", func(p *parser.PageData) *time.Duration { return &p.Timing.Connect }),
+	durationField("tls_time", "TLSTime", "tls time", func(p *parser.PageData) *time.Duration { return &p.Timing.TLS }),
+	durationField("ttfb", "TTFB", "ttfb", func(p *parser.PageData) *time.Duration { return &p.Timing.TTFB }),
+	durationField("download_time", "DownloadTime", "download time", func(p *parser.PageData) *time.Duration { return &p.Timing.Download }),
+	durationField("total_time", "TotalTime", "total time", func(p *parser.PageData) *time.Duration { return &p.Timing.Total }),
+	stringField("checksum", "Checksum", "checksum", func(p *parser.PageData) *string { return &p.Checksum }),
+	stringField("content_hash", "ContentHash", "content hash", func(p *parser.PageData) *string { return &p.ContentHash }),
+	{
+		Name:   "simhash",
+		Header: "SimHash",
+		Key:    "simhash",
+		value: func(p parser.PageData) string {
+			return fmt.Sprintf("%016x", p.SimHash)
+		},
+		get: func(p parser.PageData) any {
+			return p.SimHash
+		},
+		set: func(p *parser.PageData, value string) error {
+			if value == "" {
+				return nil
+			}
+
+			var err error
+			p.SimHash, err = strconv.ParseUint(value, 16, 64)
+			return err
+		},
+	},
+	stringField("change", "Change", "change", func(p *parser.PageData) *string { return &p.Change }),
+	stringField("error", "Error", "error", func(p *parser.PageData) *string { return &p.Error }),
+}
+
+// DefaultFields represents names of the fields saved when no fields are selected.
+var DefaultFields = []string{"url", "status_code", "title", "description", "keywords"}
+
+const extractedValuesSep = " | "
+
+// FieldNames returns names of all the registered fields.
+func FieldNames() []string {
+	names := make([]string, len(Fields))
+	for i, f := range Fields {
+		names[i] = f.Name
+	}
+
+	return names
+}
+
+// FieldByName returns the registered field by its name.
+func FieldByName(name string) (Field, bool) {
+	for _, f := range Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+
+	return Field{}, false
+}
+
+// ParseFields parses a comma-separated list of field names.
+func ParseFields(names string) ([]Field, error) {
+	var fields []Field
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		f, ok := FieldByName(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown field: %s. Supported fields: %s", name, strings.Join(FieldNames(), ", "))
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields, nil
+}
+
+// ExtractedField returns a field with the values of the user-defined field extracted by the rules.
+// Multiple values are joined with " | ".
+func ExtractedField(name string) Field {
+	return Field{
+		Name:   name,
+		Header: name,
+		Key:    name,
+		value: func(p parser.PageData) string {
+			return strings.Join(p.Fields[name], extractedValuesSep)
+		},
+		get: func(p parser.PageData) any {
+			return p.Fields[name]
+		},
+		set: func(p *parser.PageData, value string) error {
+			if value == "" {
+				return nil
+			}
+			if p.Fields == nil {
+				p.Fields = make(map[string][]string)
+			}
+			p.Fields[name] = strings.Split(value, extractedValuesSep)
+			return nil
+		},
+	}
+}
+
+func defaultFields() []Field {
+	fields := make([]Field, len(DefaultFields))
+	for i, name := range DefaultFields {
+		fields[i], _ = FieldByName(name)
+	}
+
+	return fields
+}
+
+func fieldByHeader(header string) (Field, bool) {
+	for _, f := range Fields {
+		if f.Header == header {
+			return f, true
+		}
+	}
+
+	return Field{}, false
+}
+
+// marshalRecord encodes the record to JSON with the selected fields only,
+// or with all the page data when no fields are selected.
+func marshalRecord(record parser.PageData, fields []Field) ([]byte, error) {
+	if len(fields) == 0 {
+		return json.Marshal(record)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(f.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(f.get(record))
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+func stringField(name, header, key string, ptr func(p *parser.PageData) *string) Field {
+	return Field{
+		Name:   name,
+		Header: header,
+		Key:    key,
+		value: func(p parser.PageData) string {
+			return *ptr(&p)
+		},
+		get: func(p parser.PageData) any {
+			return *ptr(&p)
+		},
+		set: func(p *parser.PageData, value string) error {
+			*ptr(p) = value
+			return nil
+		},
+	}
+}
+
+func intField(name, header, key string, ptr func(p *parser.PageData) *int) Field {
+	return Field{
+		Name:   name,
+		Header: header,
+		Key:    key,
+		value: func(p parser.PageData) string {
+			return strconv.Itoa(*ptr(&p))
+		},
+		get: func(p parser.PageData) any {
+			return *ptr(&p)
+		},
+		set: func(p *parser.PageData, value string) error {
+			v, err := parseInt64(value)
+			*ptr(p) = int(v)
+			return err
+		},
+	}
+}
+
+func openGraphField(name, header, property string) Field {
+	return Field{
+		Name:   name,
+		Header: header,
+		Key:    property,
+		value: func(p parser.PageData) string {
+			if p.Structured == nil {
+				return ""
+			}
+			return p.Structured.OpenGraph[property]
+		},
+		get: func(p parser.PageData) any {
+			if p.Structured == nil {
+				return ""
+			}
+			return p.Structured.OpenGraph[property]
+		},
+		set: func(p *parser.PageData, value string) error {
+			if value == "" {
+				return nil
+			}
+			if p.Structured == nil {
+				p.Structured = &parser.StructuredData{}
+			}
+			if p.Structured.OpenGraph == nil {
+				p.Structured.OpenGraph = make(map[string]string)
+			}
+			p.Structured.OpenGraph[property] = value
+			return nil
+		},
+	}
+}
+
+// tlsField returns a read-only field with the value of the TLS connection details.
+func tlsField(name, header, key string, value func(t *parser.TLS) string) Field {
+	return Field{
+		Name:   name,
+		Header: header,
+		Key:    key,
+		value: func(p parser.PageData) string {
+			if p.TLS == nil {
+				return ""
+			}
+			return value(p.TLS)
+		},
+		get: func(p parser.PageData) any {
+			if p.TLS == nil {
+				return ""
+			}
+			return value(p.TLS)
+		},
+	}
+}
+
+func boolField(name, header, key string, ptr func(p *parser.PageData) *bool) Field {
+	return Field{
+		Name:   name,
+		Header: header,
+		Key:    key,
+		value: func(p parser.PageData) string {
+			return strconv.FormatBool(*ptr(&p))
+		},
+		get: func(p parser.PageData) any {
+			return *ptr(&p)
+		},
+		set: func(p *parser.PageData, value string) error {
+			if value == "" {
+				return nil
+			}
+
+			var err error
+			*ptr(p), err = strconv.ParseBool(value)
+			return err
+		},
+	}
+}
+
+// durationField formats the duration in milliseconds.
+func durationField(name, header, key string, ptr func(p *parser.PageData) *time.Duration) Field {
+	return Field{
+		Name:   name,
+		Header: header,
+		Key:    key,
+		value: func(p parser.PageData) string {
+			return strconv.FormatFloat(milliseconds(*ptr(&p)), 'f', 3, 64)
+		},
+		get: func(p parser.PageData) any {
+			return milliseconds(*ptr(&p))
+		},
+		set: func(p *parser.PageData, value string) error {
+			if value == "" {
+				return nil
+			}
+
+			ms, err := strconv.ParseFloat(value, 64)
+			*ptr(p) = time.Duration(ms * float64(time.Millisecond))
+			return err
+		},
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func parseInt64(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseInt(value, 10, 64)
+}
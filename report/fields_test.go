@@ -0,0 +1,155 @@
+package report
+
+import (
+	"os"
+	"testing"
+
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/stretchr/testify/require"
+)
+
+var fieldsRecords = parser.PagesData{
+	{
+		URL:          "https://en.wikipedia.org/wiki/Ilya_Repin",
+		StatusCode:   200,
+		Title:        "Ilya Repin - Wikipedia",
+		Depth:        2,
+		ResponseTime: 120,
+		H1:           []string{"Ilya Repin", "Biography"},
+		WordCount:    350,
+		Referrer:     "https://en.wikipedia.org/wiki/Russian_painters",
+	},
+}
+
+func TestParseFields_Success(t *testing.T) {
+	fields, err := ParseFields("url, depth,h1")
+	require.NoError(t, err)
+	require.Len(t, fields, 3)
+	require.Equal(t, "url", fields[0].Name)
+	require.Equal(t, "depth", fields[1].Name)
+	require.Equal(t, "h1", fields[2].Name)
+}
+
+func TestParseFields_UnknownFieldError(t *testing.T) {
+	_, err := ParseFields("url,unknown")
+	require.Error(t, err)
+}
+
+func TestSaveBulkCSV_FieldsSuccess(t *testing.T) {
+	filePath := "result_fields_test.csv"
+	fields, err := ParseFields("url,depth,response_time,h1,word_count,referrer")
+	require.NoError(t, err)
+
+	reporter := NewCSVReport(filePath, fields...)
+	err = reporter.SaveBulk(fieldsRecords)
+	require.NoError(t, err)
+
+	defer os.Remove(filePath)
+
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.Equal(t, "URL,Depth,ResponseTime,H1,WordCount,Referrer\n"+
+		"https://en.wikipedia.org/wiki/Ilya_Repin,2,120,Ilya Repin,350,https://en.wikipedia.org/wiki/Russian_painters\n", string(content))
+
+	readRecords, err := ReadCSV(filePath)
+	require.NoError(t, err)
+	require.Equal(t, parser.PagesData{
+		{
+			URL:          "https://en.wikipedia.org/wiki/Ilya_Repin",
+			Depth:        2,
+			ResponseTime: 120,
+			H1:           []string{"Ilya Repin"},
+			WordCount:    350,
+			Referrer:     "https://en.wikipedia.org/wiki/Russian_painters",
+		},
+	}, readRecords)
+}
+
+func TestSaveBulkJSONL_FieldsSuccess(t *testing.T) {
+	filePath := "result_fields_test.jsonl"
+	fields, err := ParseFields("url,status_code,h1")
+	require.NoError(t, err)
+
+	reporter := NewJSONLReport(filePath, fields...)
+	err = reporter.SaveBulk(fieldsRecords)
+	require.NoError(t, err)
+
+	defer os.Remove(filePath)
+
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.Equal(t, `{"path":"https://en.wikipedia.org/wiki/Ilya_Repin","status code":200,"h1":["Ilya Repin","Biography"]}`+"\n", string(content))
+}
+
+func TestSaveBulkCSV_StructuredDataFieldsSuccess(t *testing.T) {
+	filePath := "result_structured_test.csv"
+	fields, err := ParseFields("url,og_title,schema_types")
+	require.NoError(t, err)
+
+	reporter := NewCSVReport(filePath, fields...)
+	err = reporter.SaveBulk(parser.PagesData{
+		{
+			URL: "https://example.com/book",
+			Structured: &parser.StructuredData{
+				JSONLD:    []parser.JSONLD{{Types: []string{"Book", "Person"}}},
+				OpenGraph: map[string]string{"og:title": "Crime and Punishment"},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(filePath)
+
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.Equal(t, "URL,OGTitle,SchemaTypes\n"+
+		"https://example.com/book,Crime and Punishment,\"Book, Person\"\n", string(content))
+
+	readRecords, err := ReadCSV(filePath)
+	require.NoError(t, err)
+	require.Equal(t, parser.PagesData{
+		{
+			URL: "https://example.com/book",
+			Structured: &parser.StructuredData{
+				OpenGraph: map[string]string{"og:title": "Crime and Punishment"},
+			},
+		},
+	}, readRecords)
+}
+
+func TestSaveBulkCSV_ExtractedFieldsSuccess(t *testing.T) {
+	filePath := "result_extracted_test.csv"
+	fields, err := ParseFields("url")
+	require.NoError(t, err)
+
+	reporter := NewCSVReport(filePath, append(fields, ExtractedField("price"), ExtractedField("images"))...)
+	err = reporter.SaveBulk(parser.PagesData{
+		{
+			URL: "https://example.com/chair",
+			Fields: map[string][]string{
+				"price":  {"120"},
+				"images": {"/a.jpg", "/b.jpg"},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	defer os.Remove(filePath)
+
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.Equal(t, "URL,price,images\n"+
+		"https://example.com/chair,120,/a.jpg | /b.jpg\n", string(content))
+
+	readRecords, err := ReadCSV(filePath)
+	require.NoError(t, err)
+	require.Equal(t, parser.PagesData{
+		{
+			URL: "https://example.com/chair",
+			Fields: map[string][]string{
+				"price":  {"120"},
+				"images": {"/a.jpg", "/b.jpg"},
+			},
+		},
+	}, readRecords)
+}
@@ -0,0 +1,23 @@
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/demyanovs/urlcrawler/parser"
+)
+
+// Read reads records from the report file. The format is detected by the file extension.
+func Read(filePath string) (parser.PagesData, error) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".csv":
+		return ReadCSV(filePath)
+	case ".json":
+		return ReadJSON(filePath)
+	case ".jsonl", ".ndjson":
+		return ReadJSONL(filePath)
+	default:
+		return nil, fmt.Errorf("unsupported report format: %s", filePath)
+	}
+}
@@ -2,25 +2,29 @@ package report
 
 import (
 	"encoding/csv"
+	"fmt"
 	"github.com/demyanovs/urlcrawler/parser"
-	"log"
 	"os"
-	"strconv"
 )
 
-var header = []string{"URL", "StatusCode", "Title", "Description", "Keywords"}
-
 // CSVReport represents a CSV report.
 type CSVReport struct {
 	filePath    string
 	firstInsert bool
+	fields      []Field
 }
 
-// NewCSVReport creates a new CSVReport.
-func NewCSVReport(filePath string) *CSVReport {
+// NewCSVReport creates a new CSVReport with the given columns.
+// The default fields are used if no fields are given.
+func NewCSVReport(filePath string, fields ...Field) *CSVReport {
+	if len(fields) == 0 {
+		fields = defaultFields()
+	}
+
 	return &CSVReport{
 		filePath:    filePath,
 		firstInsert: true,
+		fields:      fields,
 	}
 }
 
@@ -36,18 +40,20 @@ func (r *CSVReport) SaveBulk(records []parser.PageData) error {
 	}
 
 	file, err := os.OpenFile(r.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-
-	defer file.Close()
 	if err != nil {
-		log.Fatalln("failed to open file", err)
+		return err
 	}
+	defer file.Close()
 
 	w := csv.NewWriter(file)
 	defer w.Flush()
 
 	var data [][]string
 	for _, record := range records {
-		row := []string{record.URL, strconv.Itoa(record.StatusCode), record.Title, record.Desc, record.Keywords}
+		row := make([]string, len(r.fields))
+		for i, f := range r.fields {
+			row[i] = f.Value(record)
+		}
 		data = append(data, row)
 	}
 
@@ -59,6 +65,15 @@ func (r *CSVReport) SaveBulk(records []parser.PageData) error {
 	return nil
 }
 
+// Close creates the file with the header if nothing was saved.
+func (r *CSVReport) Close() error {
+	if r.firstInsert == true {
+		return r.SaveBulk(nil)
+	}
+
+	return nil
+}
+
 func (r *CSVReport) addHeader() error {
 	if _, err := os.Stat(r.filePath); err == nil {
 		err = os.Truncate(r.filePath, 0)
@@ -77,6 +92,11 @@ func (r *CSVReport) addHeader() error {
 	w := csv.NewWriter(file)
 	defer w.Flush()
 
+	header := make([]string, len(r.fields))
+	for i, f := range r.fields {
+		header[i] = f.Header
+	}
+
 	err = w.Write(header)
 	if err != nil {
 		return err
@@ -84,3 +104,51 @@ func (r *CSVReport) addHeader() error {
 
 	return nil
 }
+
+// ReadCSV reads records from the CSV report file.
+func ReadCSV(filePath string) (parser.PagesData, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	var fields []Field
+	for _, header := range rows[0] {
+		f, ok := fieldByHeader(header)
+		if !ok {
+			// Columns of the user-defined fields extracted by the rules
+			f = ExtractedField(header)
+		}
+
+		fields = append(fields, f)
+	}
+
+	var records parser.PagesData
+	for _, row := range rows[1:] {
+		var record parser.PageData
+		for i, f := range fields {
+			if f.set == nil {
+				continue
+			}
+
+			err = f.set(&record, row[i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s in %s: %s", f.Header, filePath, err)
+			}
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
@@ -84,3 +84,17 @@ func TestSaveBulkCSV_WithoutHeaderSuccess(t *testing.T) {
 
 	require.Equal(t, 3, len(rows))
 }
+
+func TestReadCSV_Success(t *testing.T) {
+	filePath := "result_read_test.csv"
+	reporter := NewCSVReport(filePath)
+
+	err := reporter.SaveBulk(records)
+	require.NoError(t, err)
+
+	defer os.Remove(filePath)
+
+	readRecords, err := ReadCSV(filePath)
+	require.NoError(t, err)
+	require.Equal(t, records, readRecords)
+}
@@ -2,79 +2,107 @@ package report
 
 import (
 	"encoding/json"
-	"github.com/demyanovs/urlcrawler/parser"
 	"os"
+
+	"github.com/demyanovs/urlcrawler/parser"
 )
 
 // JSONReport represents a JSON report.
+// Records are streamed to the file as elements of the array which is closed on Close.
 type JSONReport struct {
-	filePath    string
-	firstInsert bool
+	filePath string
+	file     *os.File
+	count    int
+	fields   []Field
 }
 
-// NewJSONReport creates a new JSONReport.
-func NewJSONReport(filePath string) *JSONReport {
+// NewJSONReport creates a new JSONReport with the given fields.
+// All the page data is saved if no fields are given.
+func NewJSONReport(filePath string, fields ...Field) *JSONReport {
 	return &JSONReport{
-		filePath:    filePath,
-		firstInsert: true,
+		filePath: filePath,
+		fields:   fields,
 	}
 }
 
 // SaveBulk saves multiple records to the file.
 func (r *JSONReport) SaveBulk(records []parser.PageData) error {
-	file, err := os.OpenFile(r.filePath, os.O_CREATE|os.O_RDWR, 0644)
-	defer file.Close()
-
-	if err != nil {
-		return err
+	if r.file == nil {
+		err := r.open()
+		if err != nil {
+			return err
+		}
 	}
 
-	// Truncate the file if it's the first insert
-	if r.firstInsert {
-		err = r.truncateFile(file)
+	for _, record := range records {
+		content, err := marshalRecord(record, r.fields)
+		if err != nil {
+			return err
+		}
+
+		separator := ",\n"
+		if r.count == 0 {
+			separator = ""
+		}
+
+		_, err = r.file.WriteString(separator + string(content))
 		if err != nil {
 			return err
 		}
 
-		r.firstInsert = false
+		r.count++
 	}
 
-	decoder := json.NewDecoder(file)
+	return nil
+}
 
-	var data []parser.PageData
-	for decoder.More() {
-		err = decoder.Decode(&data)
+// Close closes the array and the file.
+func (r *JSONReport) Close() error {
+	if r.file == nil {
+		err := r.open()
 		if err != nil {
 			return err
 		}
 	}
 
-	data = append(data, records...)
+	_, err := r.file.WriteString("\n]\n")
+	if err != nil {
+		r.file.Close()
+		return err
+	}
 
-	err = r.truncateFile(file)
+	return r.file.Close()
+}
+
+func (r *JSONReport) open() error {
+	file, err := os.OpenFile(r.filePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
 	if err != nil {
 		return err
 	}
 
-	encoder := json.NewEncoder(file)
-	err = encoder.Encode(data)
+	_, err = file.WriteString("[\n")
 	if err != nil {
+		file.Close()
 		return err
 	}
 
+	r.file = file
+
 	return nil
 }
 
-func (r *JSONReport) truncateFile(file *os.File) error {
-	err := file.Truncate(0)
+// ReadJSON reads records from the JSON report file.
+func ReadJSON(filePath string) (parser.PagesData, error) {
+	content, err := os.ReadFile(filePath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = file.Seek(0, 0)
+	var records parser.PagesData
+	err = json.Unmarshal(content, &records)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return records, nil
 }
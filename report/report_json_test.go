@@ -38,11 +38,13 @@ var data = parser.PagesData{
 func TestSaveBulkJSON_WithHeaderSuccess(t *testing.T) {
 	filePath := "result_test.json"
 	reporter := NewJSONReport(filePath)
-	reporter.firstInsert = true
 
 	err := reporter.SaveBulk(records)
 	require.NoError(t, err)
 
+	err = reporter.Close()
+	require.NoError(t, err)
+
 	defer os.Remove(filePath)
 
 	var parsedData parser.PagesData
@@ -64,3 +66,37 @@ func TestSaveBulkJSON_WithHeaderSuccess(t *testing.T) {
 	require.Equal(t, data, parsedData)
 
 }
+
+func TestReadJSON_Success(t *testing.T) {
+	filePath := "result_read_test.json"
+	reporter := NewJSONReport(filePath)
+
+	err := reporter.SaveBulk(records[:1])
+	require.NoError(t, err)
+
+	err = reporter.SaveBulk(records[1:])
+	require.NoError(t, err)
+
+	err = reporter.Close()
+	require.NoError(t, err)
+
+	defer os.Remove(filePath)
+
+	readRecords, err := ReadJSON(filePath)
+	require.NoError(t, err)
+	require.Equal(t, data, readRecords)
+}
+
+func TestCloseJSON_EmptySuccess(t *testing.T) {
+	filePath := "result_empty_test.json"
+	reporter := NewJSONReport(filePath)
+
+	err := reporter.Close()
+	require.NoError(t, err)
+
+	defer os.Remove(filePath)
+
+	readRecords, err := ReadJSON(filePath)
+	require.NoError(t, err)
+	require.Empty(t, readRecords)
+}
@@ -0,0 +1,90 @@
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+
+	"github.com/demyanovs/urlcrawler/parser"
+)
+
+// JSONLReport represents a JSON Lines (NDJSON) report.
+// Records are appended to the file, one JSON object per line.
+type JSONLReport struct {
+	filePath    string
+	firstInsert bool
+	fields      []Field
+}
+
+// NewJSONLReport creates a new JSONLReport with the given fields.
+// All the page data is saved if no fields are given.
+func NewJSONLReport(filePath string, fields ...Field) *JSONLReport {
+	return &JSONLReport{
+		filePath:    filePath,
+		firstInsert: true,
+		fields:      fields,
+	}
+}
+
+// SaveBulk saves multiple records to the file.
+func (r *JSONLReport) SaveBulk(records []parser.PageData) error {
+	flag := os.O_APPEND | os.O_CREATE | os.O_WRONLY
+	// Truncate the file if it's the first insert
+	if r.firstInsert {
+		flag |= os.O_TRUNC
+		r.firstInsert = false
+	}
+
+	file, err := os.OpenFile(r.filePath, flag, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	for _, record := range records {
+		content, err := marshalRecord(record, r.fields)
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(append(content, '\n'))
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+// Close creates the file if nothing was saved.
+func (r *JSONLReport) Close() error {
+	if r.firstInsert {
+		return r.SaveBulk(nil)
+	}
+
+	return nil
+}
+
+// ReadJSONL reads records from the JSON Lines report file.
+func ReadJSONL(filePath string) (parser.PagesData, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records parser.PagesData
+	decoder := json.NewDecoder(file)
+	for decoder.More() {
+		var record parser.PageData
+		err = decoder.Decode(&record)
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
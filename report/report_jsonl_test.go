@@ -0,0 +1,49 @@
+package report
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveBulkJSONL_Success(t *testing.T) {
+	filePath := "result_test.jsonl"
+	reporter := NewJSONLReport(filePath)
+
+	err := reporter.SaveBulk(records[:1])
+	require.NoError(t, err)
+
+	err = reporter.SaveBulk(records[1:])
+	require.NoError(t, err)
+
+	err = reporter.Close()
+	require.NoError(t, err)
+
+	defer os.Remove(filePath)
+
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.Len(t, strings.Split(strings.TrimSpace(string(content)), "\n"), 3)
+
+	readRecords, err := ReadJSONL(filePath)
+	require.NoError(t, err)
+	require.Equal(t, data, readRecords)
+}
+
+func TestSaveBulkJSONL_TruncateSuccess(t *testing.T) {
+	filePath := "result_truncate_test.jsonl"
+	err := os.WriteFile(filePath, []byte("{}\n{}\n"), 0644)
+	require.NoError(t, err)
+
+	defer os.Remove(filePath)
+
+	reporter := NewJSONLReport(filePath)
+	err = reporter.SaveBulk(records[:1])
+	require.NoError(t, err)
+
+	readRecords, err := Read(filePath)
+	require.NoError(t, err)
+	require.Equal(t, data[:1], readRecords)
+}
@@ -0,0 +1,278 @@
+package report
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/demyanovs/urlcrawler/parser"
+
+	// Registers the pure Go SQLite driver.
+	_ "modernc.org/sqlite"
+)
+
+var sqliteSchema = []string{
+	`CREATE TABLE pages (
+		id INTEGER PRIMARY KEY,
+		url TEXT NOT NULL UNIQUE,
+		status_code INTEGER NOT NULL,
+		title TEXT NOT NULL,
+		description TEXT NOT NULL,
+		keywords TEXT NOT NULL,
+		depth INTEGER NOT NULL,
+		content_type TEXT NOT NULL,
+		response_time INTEGER NOT NULL,
+		size INTEGER NOT NULL,
+		canonical TEXT NOT NULL,
+		h1 TEXT NOT NULL,
+		word_count INTEGER NOT NULL,
+		referrer TEXT NOT NULL,
+		dns_time REAL NOT NULL,
+		connect_time REAL NOT NULL,
+		tls_time REAL NOT NULL,
+		ttfb REAL NOT NULL,
+		download_time REAL NOT NULL,
+		total_time REAL NOT NULL,
+		checksum TEXT NOT NULL,
+		content_hash TEXT NOT NULL,
+		simhash TEXT NOT NULL,
+		change TEXT NOT NULL
+	)`,
+	`CREATE TABLE links (
+		page_id INTEGER NOT NULL REFERENCES pages(id),
+		url TEXT NOT NULL
+	)`,
+	`CREATE TABLE redirects (
+		page_id INTEGER NOT NULL REFERENCES pages(id),
+		position INTEGER NOT NULL,
+		url TEXT NOT NULL,
+		status_code INTEGER NOT NULL
+	)`,
+	`CREATE TABLE errors (
+		page_id INTEGER NOT NULL REFERENCES pages(id),
+		message TEXT NOT NULL
+	)`,
+	`CREATE TABLE headers (
+		page_id INTEGER NOT NULL REFERENCES pages(id),
+		name TEXT NOT NULL,
+		value TEXT NOT NULL
+	)`,
+	`CREATE TABLE fields (
+		page_id INTEGER NOT NULL REFERENCES pages(id),
+		name TEXT NOT NULL,
+		value TEXT NOT NULL
+	)`,
+	`CREATE TABLE assets (
+		page_id INTEGER NOT NULL REFERENCES pages(id),
+		url TEXT NOT NULL,
+		type TEXT NOT NULL
+	)`,
+	`CREATE INDEX pages_status_code ON pages(status_code)`,
+	`CREATE INDEX pages_depth ON pages(depth)`,
+	`CREATE INDEX links_page_id ON links(page_id)`,
+	`CREATE INDEX links_url ON links(url)`,
+	`CREATE INDEX redirects_page_id ON redirects(page_id)`,
+	`CREATE INDEX errors_page_id ON errors(page_id)`,
+	`CREATE INDEX headers_page_id_name ON headers(page_id, name)`,
+	`CREATE INDEX fields_page_id_name ON fields(page_id, name)`,
+	`CREATE INDEX assets_page_id ON assets(page_id)`,
+	`CREATE INDEX assets_url ON assets(url)`,
+}
+
+// sqlitePageTables are the tables of the details of the pages referencing the pages table.
+var sqlitePageTables = []string{"links", "redirects", "errors", "headers", "fields", "assets"}
+
+// SQLiteReport represents a SQLite report.
+// Pages are saved to the pages table, their links, redirects, errors,
+// response headers, user-defined fields and assets to the separate tables.
+type SQLiteReport struct {
+	filePath string
+	db       *sql.DB
+}
+
+// NewSQLiteReport creates a new SQLiteReport.
+func NewSQLiteReport(filePath string) *SQLiteReport {
+	return &SQLiteReport{
+		filePath: filePath,
+	}
+}
+
+// SaveBulk saves multiple records to the database in a single transaction.
+func (r *SQLiteReport) SaveBulk(records []parser.PageData) error {
+	if r.db == nil {
+		err := r.open()
+		if err != nil {
+			return err
+		}
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		err = r.insert(tx, record)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Close closes the database.
+func (r *SQLiteReport) Close() error {
+	if r.db == nil {
+		err := r.open()
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.db.Close()
+}
+
+func (r *SQLiteReport) open() error {
+	// Start with a new database on every crawl
+	err := os.Remove(r.filePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	db, err := sql.Open("sqlite", r.filePath)
+	if err != nil {
+		return err
+	}
+
+	for _, query := range sqliteSchema {
+		_, err = db.Exec(query)
+		if err != nil {
+			db.Close()
+			return err
+		}
+	}
+
+	r.db = db
+
+	return nil
+}
+
+func (r *SQLiteReport) insert(tx *sql.Tx, record parser.PageData) error {
+	var h1 string
+	if len(record.H1) > 0 {
+		h1 = record.H1[0]
+	}
+
+	// The page is updated in place if its URL is saved again, e.g. when two URLs redirect to the same page,
+	// so the id is kept and the rows of the other tables are replaced below
+	var pageID int64
+	err := tx.QueryRow(
+		`INSERT INTO pages (
+			url, status_code, title, description, keywords, depth, content_type,
+			response_time, size, canonical, h1, word_count, referrer, dns_time,
+			connect_time, tls_time, ttfb, download_time, total_time, checksum,
+			content_hash, simhash, change
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(url) DO UPDATE SET
+			status_code = excluded.status_code, title = excluded.title, description = excluded.description,
+			keywords = excluded.keywords, depth = excluded.depth, content_type = excluded.content_type,
+			response_time = excluded.response_time, size = excluded.size, canonical = excluded.canonical,
+			h1 = excluded.h1, word_count = excluded.word_count, referrer = excluded.referrer,
+			dns_time = excluded.dns_time, connect_time = excluded.connect_time, tls_time = excluded.tls_time,
+			ttfb = excluded.ttfb, download_time = excluded.download_time, total_time = excluded.total_time,
+			checksum = excluded.checksum, content_hash = excluded.content_hash, simhash = excluded.simhash,
+			change = excluded.change
+		RETURNING id`,
+		record.URL,
+		record.StatusCode,
+		record.Title,
+		record.Desc,
+		record.Keywords,
+		record.Depth,
+		record.ContentType,
+		record.ResponseTime,
+		record.Size,
+		record.Canonical,
+		h1,
+		record.WordCount,
+		record.Referrer,
+		milliseconds(record.Timing.DNS),
+		milliseconds(record.Timing.Connect),
+		milliseconds(record.Timing.TLS),
+		milliseconds(record.Timing.TTFB),
+		milliseconds(record.Timing.Download),
+		milliseconds(record.Timing.Total),
+		record.Checksum,
+		record.ContentHash,
+		fmt.Sprintf("%016x", record.SimHash),
+		record.Change,
+	).Scan(&pageID)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range sqlitePageTables {
+		_, err = tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE page_id = ?`, table), pageID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, link := range record.Links {
+		_, err = tx.Exec(`INSERT INTO links (page_id, url) VALUES (?, ?)`, pageID, link)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, redirect := range record.Redirects {
+		_, err = tx.Exec(
+			`INSERT INTO redirects (page_id, position, url, status_code) VALUES (?, ?, ?, ?)`,
+			pageID,
+			i,
+			redirect.URL,
+			redirect.StatusCode,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if record.Error != "" {
+		_, err = tx.Exec(`INSERT INTO errors (page_id, message) VALUES (?, ?)`, pageID, record.Error)
+		if err != nil {
+			return err
+		}
+	}
+
+	for name, values := range record.Headers {
+		for _, value := range values {
+			_, err = tx.Exec(`INSERT INTO headers (page_id, name, value) VALUES (?, ?, ?)`, pageID, name, value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for name, values := range record.Fields {
+		for _, value := range values {
+			_, err = tx.Exec(`INSERT INTO fields (page_id, name, value) VALUES (?, ?, ?)`, pageID, name, value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, asset := range record.Assets {
+		_, err = tx.Exec(`INSERT INTO assets (page_id, url, type) VALUES (?, ?, ?)`, pageID, asset.URL, asset.Type)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package report
+
+import (
+	"database/sql"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveBulkSQLite_Success(t *testing.T) {
+	filePath := "result_test.sqlite"
+	reporter := NewSQLiteReport(filePath)
+
+	err := reporter.SaveBulk(records)
+	require.NoError(t, err)
+
+	err = reporter.SaveBulk(parser.PagesData{
+		{
+			URL:        "https://en.wikipedia.org/wiki/Leo_Tolstoy",
+			StatusCode: 404,
+			Depth:      2,
+			Links:      []string{"https://en.wikipedia.org/wiki/War_and_Peace"},
+			Redirects:  []parser.Redirect{{URL: "https://en.wikipedia.org/wiki/Tolstoy", StatusCode: 301}},
+			Headers:    http.Header{"Content-Type": {"text/html"}},
+			Assets:     []parser.Asset{{URL: "https://en.wikipedia.org/static/logo.png", Type: parser.AssetImage}},
+			Fields:     map[string][]string{"author": {"Leo Tolstoy"}},
+			Error:      "returned status: 404 Not Found",
+		},
+	})
+	require.NoError(t, err)
+
+	err = reporter.Close()
+	require.NoError(t, err)
+
+	defer os.Remove(filePath)
+
+	db, err := sql.Open("sqlite", filePath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var count int
+	err = db.QueryRow(`SELECT COUNT(*) FROM pages WHERE title = '' AND depth <= 2`).Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+
+	for _, table := range []string{"links", "redirects", "errors", "headers", "fields", "assets"} {
+		err = db.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 1, count, table)
+	}
+}
+
+func TestSaveBulkSQLite_SameURLSuccess(t *testing.T) {
+	filePath := "result_same_url_test.sqlite"
+	reporter := NewSQLiteReport(filePath)
+	defer os.Remove(filePath)
+
+	// Two URLs redirected to the same page
+	page := parser.PageData{
+		URL:        "https://example.com/b",
+		StatusCode: 200,
+		Title:      "B",
+		Links:      []string{"https://example.com/c"},
+		Redirects:  []parser.Redirect{{URL: "https://example.com/a", StatusCode: 301}},
+		Headers:    http.Header{"Content-Type": {"text/html"}},
+	}
+	err := reporter.SaveBulk(parser.PagesData{page})
+	require.NoError(t, err)
+
+	page.Title = "B2"
+	page.Redirects = []parser.Redirect{{URL: "https://example.com/old-b", StatusCode: 301}}
+	err = reporter.SaveBulk(parser.PagesData{page})
+	require.NoError(t, err)
+
+	err = reporter.Close()
+	require.NoError(t, err)
+
+	db, err := sql.Open("sqlite", filePath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var pageID int64
+	var title string
+	err = db.QueryRow(`SELECT id, title FROM pages`).Scan(&pageID, &title)
+	require.NoError(t, err)
+	require.Equal(t, "B2", title)
+
+	for _, table := range []string{"links", "redirects", "headers"} {
+		var count int
+		err = db.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE page_id = ?`, pageID).Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 1, count, table)
+
+		err = db.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 1, count, table)
+	}
+
+	var redirectURL string
+	err = db.QueryRow(`SELECT url FROM redirects`).Scan(&redirectURL)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/old-b", redirectURL)
+}
@@ -0,0 +1,12 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRead_UnsupportedFormatError(t *testing.T) {
+	_, err := Read("result.txt")
+	require.Error(t, err)
+}
@@ -0,0 +1,195 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/demyanovs/urlcrawler/parser"
+)
+
+// Change represents a change of the page since the previous crawl.
+type Change string
+
+// Possible changes of the page since the previous crawl.
+const (
+	ChangeNew       Change = "new"
+	ChangeChanged   Change = "changed"
+	ChangeUnchanged Change = "unchanged"
+	ChangeRemoved   Change = "removed"
+)
+
+// Entry represents a state of the URL saved after the crawl.
+type Entry struct {
+	ETag         string          `json:"etag,omitempty"`
+	LastModified string          `json:"last_modified,omitempty"`
+	Page         parser.PageData `json:"page"`
+	Links        []string        `json:"links,omitempty"`
+}
+
+// Changes represents URLs grouped by the change since the previous crawl.
+type Changes struct {
+	New       []string
+	Changed   []string
+	Unchanged []string
+	Removed   []string
+}
+
+// State represents a crawl state which is loaded from the previous run
+// and saved for the next one.
+type State struct {
+	mu         sync.RWMutex
+	filePath   string
+	previous   map[string]Entry
+	current    map[string]Entry
+	changes    map[string]Change
+	incomplete bool
+}
+
+type stateFile struct {
+	Pages map[string]Entry `json:"pages"`
+}
+
+// New creates a new empty state.
+func New(filePath string) *State {
+	return &State{
+		filePath: filePath,
+		previous: make(map[string]Entry),
+		current:  make(map[string]Entry),
+		changes:  make(map[string]Change),
+	}
+}
+
+// Load loads the state from the file. A missing file results in an empty state.
+func Load(filePath string) (*State, error) {
+	s := New(filePath)
+
+	content, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f stateFile
+	err = json.Unmarshal(content, &f)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Pages != nil {
+		s.previous = f.Pages
+	}
+
+	return s, nil
+}
+
+// Previous returns the entry for the URL from the previous crawl.
+func (s *State) Previous(URL string) (Entry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.previous[URL]
+
+	return entry, ok
+}
+
+// Record saves the entry for the URL and returns its change since the previous crawl.
+func (s *State) Record(URL string, entry Entry) Change {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry.Page.Change = ""
+
+	change := ChangeNew
+	if prev, ok := s.previous[URL]; ok {
+		change = ChangeChanged
+		if prev.Page.StatusCode == entry.Page.StatusCode && prev.Page.Checksum == entry.Page.Checksum {
+			change = ChangeUnchanged
+		}
+	}
+
+	s.current[URL] = entry
+	s.changes[URL] = change
+
+	return change
+}
+
+// Keep keeps the entry of the previous crawl for the URL which couldn't be fetched,
+// so it's neither changed nor removed and its conditional headers are sent on the next run.
+func (s *State) Keep(URL string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if prev, ok := s.previous[URL]; ok {
+		s.current[URL] = prev
+	}
+}
+
+// MarkIncomplete marks the crawl as stopped before all the URLs were reached, e.g. interrupted or by the limit.
+// The URLs from the previous crawl which were not recorded aren't considered removed then and are saved as is.
+func (s *State) MarkIncomplete() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.incomplete = true
+}
+
+// Changes returns URLs grouped by the change since the previous crawl.
+// URLs from the previous crawl which were not recorded are considered removed unless the crawl is incomplete.
+func (s *State) Changes() Changes {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var changes Changes
+	for URL, change := range s.changes {
+		switch change {
+		case ChangeNew:
+			changes.New = append(changes.New, URL)
+		case ChangeChanged:
+			changes.Changed = append(changes.Changed, URL)
+		case ChangeUnchanged:
+			changes.Unchanged = append(changes.Unchanged, URL)
+		}
+	}
+
+	for URL := range s.previous {
+		if _, ok := s.current[URL]; !ok && !s.incomplete {
+			changes.Removed = append(changes.Removed, URL)
+		}
+	}
+
+	sort.Strings(changes.New)
+	sort.Strings(changes.Changed)
+	sort.Strings(changes.Unchanged)
+	sort.Strings(changes.Removed)
+
+	return changes
+}
+
+// Save saves the recorded entries to the file. The entries of the previous crawl which were not recorded
+// are saved too if the crawl is incomplete, otherwise they're removed.
+func (s *State) Save() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pages := make(map[string]Entry, len(s.current))
+	if s.incomplete {
+		for URL, entry := range s.previous {
+			pages[URL] = entry
+		}
+	}
+	for URL, entry := range s.current {
+		pages[URL] = entry
+	}
+
+	content, err := json.Marshal(stateFile{Pages: pages})
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filePath, content, 0644)
+}
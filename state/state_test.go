@@ -0,0 +1,107 @@
+package state
+
+import (
+	"os"
+	"testing"
+
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_MissingFileSuccess(t *testing.T) {
+	s, err := Load("missing_state_test.json")
+	require.NoError(t, err)
+
+	_, ok := s.Previous("https://example.com")
+	require.False(t, ok)
+}
+
+func TestLoad_InvalidFileError(t *testing.T) {
+	filePath := "invalid_state_test.json"
+	err := os.WriteFile(filePath, []byte("{"), 0644)
+	require.NoError(t, err)
+
+	defer os.Remove(filePath)
+
+	_, err = Load(filePath)
+	require.Error(t, err)
+}
+
+func TestRecord_Success(t *testing.T) {
+	filePath := "state_test.json"
+	defer os.Remove(filePath)
+
+	s := New(filePath)
+	s.Record("https://example.com/a", Entry{ETag: `"a"`, Page: parser.PageData{StatusCode: 200, Checksum: "a"}})
+	s.Record("https://example.com/b", Entry{Page: parser.PageData{StatusCode: 200, Checksum: "b"}})
+	s.Record("https://example.com/c", Entry{Page: parser.PageData{StatusCode: 200, Checksum: "c"}})
+	require.NoError(t, s.Save())
+
+	s, err := Load(filePath)
+	require.NoError(t, err)
+
+	prev, ok := s.Previous("https://example.com/a")
+	require.True(t, ok)
+	require.Equal(t, `"a"`, prev.ETag)
+
+	require.Equal(t, ChangeUnchanged, s.Record("https://example.com/a", prev))
+	require.Equal(t, ChangeChanged, s.Record("https://example.com/b", Entry{Page: parser.PageData{StatusCode: 200, Checksum: "b2"}}))
+	require.Equal(t, ChangeNew, s.Record("https://example.com/d", Entry{Page: parser.PageData{StatusCode: 200, Checksum: "d"}}))
+
+	require.Equal(t, Changes{
+		New:       []string{"https://example.com/d"},
+		Changed:   []string{"https://example.com/b"},
+		Unchanged: []string{"https://example.com/a"},
+		Removed:   []string{"https://example.com/c"},
+	}, s.Changes())
+}
+
+func TestSave_IncompleteSuccess(t *testing.T) {
+	filePath := "incomplete_state_test.json"
+	defer os.Remove(filePath)
+
+	s := New(filePath)
+	s.Record("https://example.com/a", Entry{ETag: `"a"`, Page: parser.PageData{StatusCode: 200, Checksum: "a"}})
+	s.Record("https://example.com/b", Entry{ETag: `"b"`, Page: parser.PageData{StatusCode: 200, Checksum: "b"}})
+	s.Record("https://example.com/c", Entry{ETag: `"c"`, Page: parser.PageData{StatusCode: 200, Checksum: "c"}})
+	require.NoError(t, s.Save())
+
+	// The second run fails to fetch b and stops before c
+	s, err := Load(filePath)
+	require.NoError(t, err)
+	s.Record("https://example.com/a", Entry{ETag: `"a2"`, Page: parser.PageData{StatusCode: 200, Checksum: "a2"}})
+	s.Keep("https://example.com/b")
+	s.MarkIncomplete()
+
+	require.Equal(t, Changes{Changed: []string{"https://example.com/a"}}, s.Changes())
+	require.NoError(t, s.Save())
+
+	s, err = Load(filePath)
+	require.NoError(t, err)
+	for URL, etag := range map[string]string{"a": `"a2"`, "b": `"b"`, "c": `"c"`} {
+		prev, ok := s.Previous("https://example.com/" + URL)
+		require.True(t, ok)
+		require.Equal(t, etag, prev.ETag)
+	}
+}
+
+func TestSave_CompleteRemovesSuccess(t *testing.T) {
+	filePath := "complete_state_test.json"
+	defer os.Remove(filePath)
+
+	s := New(filePath)
+	s.Record("https://example.com/a", Entry{Page: parser.PageData{StatusCode: 200, Checksum: "a"}})
+	s.Record("https://example.com/b", Entry{Page: parser.PageData{StatusCode: 200, Checksum: "b"}})
+	require.NoError(t, s.Save())
+
+	s, err := Load(filePath)
+	require.NoError(t, err)
+	s.Record("https://example.com/a", Entry{Page: parser.PageData{StatusCode: 200, Checksum: "a"}})
+	require.Equal(t, []string{"https://example.com/b"}, s.Changes().Removed)
+	require.NoError(t, s.Save())
+
+	s, err = Load(filePath)
+	require.NoError(t, err)
+	_, ok := s.Previous("https://example.com/b")
+	require.False(t, ok)
+}
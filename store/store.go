@@ -101,5 +101,8 @@ func (s *Store) Clear() {
 
 // Len returns the number of elements in the store.
 func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	return len(s.m)
 }
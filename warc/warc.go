@@ -0,0 +1,442 @@
+// Package warc implements a writer of the crawled requests and responses in WARC 1.1 format
+// to archive the crawls and replay them with the standard tools.
+package warc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/demyanovs/urlcrawler/middleware"
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/demyanovs/urlcrawler/queue"
+)
+
+// Types of the WARC records.
+const (
+	TypeWarcinfo = "warcinfo"
+	TypeRequest  = "request"
+	TypeResponse = "response"
+	TypeMetadata = "metadata"
+)
+
+const (
+	version        = "WARC/1.1"
+	dateFormat     = "2006-01-02T15:04:05Z"
+	contentTypeReq = "application/http;msgtype=request"
+	contentTypeRes = "application/http;msgtype=response"
+	contentTypeKV  = "application/warc-fields"
+	software       = "urlcrawler"
+	conformsTo     = "https://iipc.github.io/warc-specifications/specifications/warc-format/warc-1.1/"
+)
+
+// Metadata represents the crawl details of the exchange saved in the metadata record.
+type Metadata struct {
+	Depth    int
+	Referrer string
+	Links    []string
+}
+
+// Writer represents a writer of the WARC files. Every record is compressed as a separate gzip member.
+// A new file, starting with the warcinfo record, is created when the size of the current file reaches MaxSize.
+type Writer struct {
+	Dir       string
+	Prefix    string
+	MaxSize   int64
+	mu        sync.Mutex
+	file      *os.File
+	size      int64
+	serial    int
+	startedAt time.Time
+	infoID    string
+	files     []string
+}
+
+// NewWriter creates a new Writer of the files named "<prefix>-<timestamp>-<serial>.warc.gz" in the directory.
+// MaxSize is in bytes, 0 means unlimited.
+func NewWriter(dir string, prefix string, maxSize int64) (*Writer, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Writer{
+		Dir:       dir,
+		Prefix:    prefix,
+		MaxSize:   maxSize,
+		startedAt: time.Now(),
+	}, nil
+}
+
+// Middleware returns the middleware of the queue which records the fetched pages and their redirects
+// with the links found by the parser. The requests are recorded with the headers written by the transport,
+// e.g. User-Agent and Accept-Encoding.
+func (w *Writer) Middleware() queue.Middleware {
+	fetch := middleware.Fetch(func(ctx context.Context, req *queue.Request, next middleware.FetchFunc) (*http.Response, parser.Timing, error) {
+		sent := &sentHeaders{}
+		resp, timing, err := next(httptrace.WithClientTrace(ctx, sent.trace()), req)
+		if err != nil {
+			return resp, timing, err
+		}
+
+		sent.apply(resp)
+
+		return resp, timing, nil
+	})
+
+	parse := middleware.Parse(func(ctx context.Context, req *queue.Request, resp *http.Response, next middleware.ParseFunc) (parser.PageData, []string, error) {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return parser.PageData{}, nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		pageData, links, parseErr := next(ctx, req, resp)
+
+		// The links are relative to the root of the host of the page
+		root := fmt.Sprintf("%s://%s/", resp.Request.URL.Scheme, resp.Request.URL.Host)
+		fullLinks := make([]string, 0, len(links))
+		for _, l := range links {
+			fullLinks = append(fullLinks, root+l)
+		}
+
+		err = w.WriteExchange(resp, body, Metadata{
+			Depth:    req.Depth,
+			Referrer: req.Referrer,
+			Links:    fullLinks,
+		})
+		if err != nil {
+			return pageData, links, fmt.Errorf("can't write warc records: %s", err)
+		}
+
+		return pageData, links, parseErr
+	})
+
+	return func(next queue.Handler) queue.Handler {
+		return parse(fetch(next))
+	}
+}
+
+// WriteExchange writes the request, the response with the body and the metadata records of the response.
+// The redirects which led to the response are written before it as the request and response records
+// without the bodies, which are discarded by the client.
+// The records of the exchange are written to the same file.
+func (w *Writer) WriteExchange(resp *http.Response, body []byte, meta Metadata) error {
+	URL := resp.Request.URL.String()
+
+	var redirects []*http.Response
+	for r := resp.Request.Response; r != nil && r.Request != nil; r = r.Request.Response {
+		redirects = append([]*http.Response{r}, redirects...)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := w.rotate()
+	if err != nil {
+		return err
+	}
+
+	date := time.Now().UTC().Format(dateFormat)
+
+	for _, r := range redirects {
+		_, err = w.writeResponse(r, nil, date)
+		if err != nil {
+			return err
+		}
+	}
+
+	responseID, err := w.writeResponse(resp, body, date)
+	if err != nil {
+		return err
+	}
+
+	fields := [][2]string{{"hopsFromSeed", strconv.Itoa(meta.Depth)}}
+	if meta.Referrer != "" {
+		fields = append(fields, [2]string{"via", meta.Referrer})
+	}
+	for _, l := range meta.Links {
+		fields = append(fields, [2]string{"outlink", l})
+	}
+
+	return w.writeRecord([][2]string{
+		{"WARC-Type", TypeMetadata},
+		{"WARC-Record-ID", recordID()},
+		{"WARC-Warcinfo-ID", w.infoID},
+		{"WARC-Date", date},
+		{"WARC-Target-URI", URL},
+		{"WARC-Refers-To", responseID},
+		{"Content-Type", contentTypeKV},
+	}, warcFields(fields))
+}
+
+// writeResponse writes the response and the request records of the response and returns the ID of the response record.
+func (w *Writer) writeResponse(resp *http.Response, body []byte, date string) (string, error) {
+	URL := resp.Request.URL.String()
+
+	reqDump, err := dumpRequest(resp.Request)
+	if err != nil {
+		return "", err
+	}
+
+	respDump, err := dumpResponse(resp, body)
+	if err != nil {
+		return "", err
+	}
+
+	responseID := recordID()
+
+	err = w.writeRecord([][2]string{
+		{"WARC-Type", TypeResponse},
+		{"WARC-Record-ID", responseID},
+		{"WARC-Warcinfo-ID", w.infoID},
+		{"WARC-Date", date},
+		{"WARC-Target-URI", URL},
+		{"WARC-Payload-Digest", digest(body)},
+		{"Content-Type", contentTypeRes},
+	}, respDump)
+	if err != nil {
+		return "", err
+	}
+
+	err = w.writeRecord([][2]string{
+		{"WARC-Type", TypeRequest},
+		{"WARC-Record-ID", recordID()},
+		{"WARC-Warcinfo-ID", w.infoID},
+		{"WARC-Date", date},
+		{"WARC-Target-URI", URL},
+		{"WARC-Concurrent-To", responseID},
+		{"Content-Type", contentTypeReq},
+	}, reqDump)
+	if err != nil {
+		return "", err
+	}
+
+	return responseID, nil
+}
+
+// Files returns the paths of the written files.
+func (w *Writer) Files() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]string(nil), w.files...)
+}
+
+// Close closes the current file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+
+	err := w.file.Close()
+	w.file = nil
+
+	return err
+}
+
+// rotate creates a new file with the warcinfo record if there is no file yet or the current one is full.
+func (w *Writer) rotate() error {
+	if w.file != nil && (w.MaxSize <= 0 || w.size < w.MaxSize) {
+		return nil
+	}
+
+	if w.file != nil {
+		err := w.file.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	name := fmt.Sprintf("%s-%s-%05d.warc.gz", w.Prefix, w.startedAt.UTC().Format("20060102150405"), w.serial)
+	file, err := os.Create(filepath.Join(w.Dir, name))
+	if err != nil {
+		return err
+	}
+
+	w.file = file
+	w.size = 0
+	w.serial++
+	w.files = append(w.files, file.Name())
+	w.infoID = recordID()
+
+	return w.writeRecord([][2]string{
+		{"WARC-Type", TypeWarcinfo},
+		{"WARC-Record-ID", w.infoID},
+		{"WARC-Date", time.Now().UTC().Format(dateFormat)},
+		{"WARC-Filename", name},
+		{"Content-Type", contentTypeKV},
+	}, warcFields([][2]string{
+		{"software", software},
+		{"format", "WARC File Format 1.1"},
+		{"conformsTo", conformsTo},
+	}))
+}
+
+// writeRecord writes the record with the headers and the block as a separate gzip member.
+func (w *Writer) writeRecord(headers [][2]string, block []byte) error {
+	var record bytes.Buffer
+	record.WriteString(version + "\r\n")
+	for _, h := range headers {
+		record.WriteString(h[0] + ": " + h[1] + "\r\n")
+	}
+	record.WriteString("WARC-Block-Digest: " + digest(block) + "\r\n")
+	record.WriteString("Content-Length: " + strconv.Itoa(len(block)) + "\r\n")
+	record.WriteString("\r\n")
+	record.Write(block)
+	record.WriteString("\r\n\r\n")
+
+	counter := &countingWriter{w: w.file}
+	gz := gzip.NewWriter(counter)
+	_, err := gz.Write(record.Bytes())
+	if err != nil {
+		return err
+	}
+
+	err = gz.Close()
+	w.size += counter.n
+
+	return err
+}
+
+// dumpRequest returns the request line and the headers of the request as sent.
+func dumpRequest(req *http.Request) ([]byte, error) {
+	var b bytes.Buffer
+	requestURI := req.URL.RequestURI()
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	fmt.Fprintf(&b, "%s %s HTTP/1.1\r\n", method, requestURI)
+	fmt.Fprintf(&b, "Host: %s\r\n", req.URL.Host)
+
+	err := req.Header.WriteSubset(&b, map[string]bool{"Host": true})
+	if err != nil {
+		return nil, err
+	}
+	b.WriteString("\r\n")
+
+	return b.Bytes(), nil
+}
+
+// sentHeaders records the headers of the requests written by the transport, one per request of the redirects.
+type sentHeaders struct {
+	mu      sync.Mutex
+	current http.Header
+	written []http.Header
+}
+
+// trace returns the client trace which records the written headers.
+// The pseudo-headers of HTTP/2 are skipped and the names are canonicalized as in HTTP/1.1.
+func (s *sentHeaders) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		WroteHeaderField: func(key string, value []string) {
+			if strings.HasPrefix(key, ":") {
+				return
+			}
+
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			if s.current == nil {
+				s.current = http.Header{}
+			}
+			key = http.CanonicalHeaderKey(key)
+			s.current[key] = append(s.current[key], value...)
+		},
+		WroteHeaders: func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			s.written = append(s.written, s.current)
+			s.current = nil
+		},
+	}
+}
+
+// apply replaces the headers of the requests of the response and its redirects with the written ones.
+// The requests are matched from the last one, the requests without the written headers,
+// e.g. of the responses from the cache, are kept as is.
+func (s *sentHeaders) apply(resp *http.Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := len(s.written) - 1
+	for r := resp; r != nil && r.Request != nil && i >= 0; r = r.Request.Response {
+		if s.written[i] != nil {
+			r.Request.Header = s.written[i]
+		}
+		i--
+	}
+}
+
+// dumpResponse returns the status line, the headers and the body of the response.
+func dumpResponse(resp *http.Response, body []byte) ([]byte, error) {
+	copied := *resp
+	copied.Body = io.NopCloser(bytes.NewReader(body))
+	copied.ContentLength = int64(len(body))
+	copied.TransferEncoding = nil
+	copied.Header = resp.Header.Clone()
+	copied.Header.Del("Transfer-Encoding")
+
+	return httputil.DumpResponse(&copied, true)
+}
+
+// warcFields returns the block of the application/warc-fields records.
+func warcFields(fields [][2]string) []byte {
+	var b bytes.Buffer
+	for _, f := range fields {
+		b.WriteString(f[0] + ": " + f[1] + "\r\n")
+	}
+
+	return b.Bytes()
+}
+
+// digest returns the SHA-1 digest of the data in base32 as used by WARC.
+func digest(data []byte) string {
+	sum := sha1.Sum(data)
+
+	return "sha1:" + base32.StdEncoding.EncodeToString(sum[:])
+}
+
+// recordID returns a new random record ID.
+func recordID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("<urn:uuid:%x-%x-%x-%x-%x>", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write writes to the underlying writer.
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+
+	return n, err
+}
@@ -0,0 +1,237 @@
+package warc
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/demyanovs/urlcrawler/fetcher"
+	"github.com/demyanovs/urlcrawler/parser"
+	"github.com/demyanovs/urlcrawler/queue"
+	"github.com/stretchr/testify/require"
+)
+
+const testWARCDir = "test_warc"
+
+type record struct {
+	header textproto.MIMEHeader
+	block  string
+}
+
+// readRecords reads the records of the file checking that every record is a separate gzip member.
+func readRecords(t *testing.T, filePath string) []record {
+	file, err := os.Open(filePath)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var records []record
+	reader := bufio.NewReader(file)
+	gz, err := gzip.NewReader(reader)
+	require.NoError(t, err)
+
+	for {
+		gz.Multistream(false)
+
+		member, err := io.ReadAll(gz)
+		require.NoError(t, err)
+
+		tp := textproto.NewReader(bufio.NewReader(strings.NewReader(string(member))))
+		line, err := tp.ReadLine()
+		require.NoError(t, err)
+		require.Equal(t, "WARC/1.1", line)
+
+		header, err := tp.ReadMIMEHeader()
+		require.NoError(t, err)
+
+		length, err := strconv.Atoi(header.Get("Content-Length"))
+		require.NoError(t, err)
+
+		block := make([]byte, length)
+		_, err = io.ReadFull(tp.R, block)
+		require.NoError(t, err)
+
+		rest, err := io.ReadAll(tp.R)
+		require.NoError(t, err)
+		require.Equal(t, "\r\n\r\n", string(rest))
+		require.Equal(t, digest(block), header.Get("WARC-Block-Digest"))
+
+		records = append(records, record{header: header, block: string(block)})
+
+		err = gz.Reset(reader)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	return records
+}
+
+func TestWriteExchange_Success(t *testing.T) {
+	defer os.RemoveAll(testWARCDir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><body><a href="/about">About</a></body></html>`))
+	}))
+	defer server.Close()
+
+	w, err := NewWriter(testWARCDir, "crawl", 0)
+	require.NoError(t, err)
+
+	h := w.Middleware()(fetchHandler{})
+	req := &queue.Request{URL: server.URL + "/", Header: http.Header{"If-None-Match": {`"v1"`}}, Depth: 1, Referrer: server.URL + "/start"}
+	resp, _, err := h.Fetch(context.Background(), req)
+	require.NoError(t, err)
+
+	_, links, err := h.Parse(context.Background(), req, resp)
+	require.NoError(t, err)
+	require.Equal(t, []string{"about"}, links)
+	require.NoError(t, w.Close())
+
+	files := w.Files()
+	require.Len(t, files, 1)
+	require.Regexp(t, `crawl-\d{14}-00000\.warc\.gz$`, files[0])
+
+	records := readRecords(t, files[0])
+	require.Len(t, records, 4)
+
+	info := records[0]
+	require.Equal(t, TypeWarcinfo, info.header.Get("WARC-Type"))
+	require.Contains(t, info.block, "software: urlcrawler\r\n")
+
+	response := records[1]
+	require.Equal(t, TypeResponse, response.header.Get("WARC-Type"))
+	require.Equal(t, server.URL+"/", response.header.Get("WARC-Target-URI"))
+	require.Equal(t, info.header.Get("WARC-Record-ID"), response.header.Get("WARC-Warcinfo-ID"))
+	require.Equal(t, "application/http;msgtype=response", response.header.Get("Content-Type"))
+	require.Equal(t, digest([]byte(`<html><body><a href="/about">About</a></body></html>`)), response.header.Get("WARC-Payload-Digest"))
+	require.True(t, strings.HasPrefix(response.block, "HTTP/1.1 200 OK\r\n"))
+	require.True(t, strings.HasSuffix(response.block, "\r\n\r\n<html><body><a href=\"/about\">About</a></body></html>"))
+
+	request := records[2]
+	require.Equal(t, TypeRequest, request.header.Get("WARC-Type"))
+	require.Equal(t, response.header.Get("WARC-Record-ID"), request.header.Get("WARC-Concurrent-To"))
+	require.True(t, strings.HasPrefix(request.block, "GET / HTTP/1.1\r\nHost: "+strings.TrimPrefix(server.URL, "http://")+"\r\n"))
+	require.Contains(t, request.block, "If-None-Match: \"v1\"\r\n")
+
+	metadata := records[3]
+	require.Equal(t, TypeMetadata, metadata.header.Get("WARC-Type"))
+	require.Equal(t, response.header.Get("WARC-Record-ID"), metadata.header.Get("WARC-Refers-To"))
+	require.Equal(t, "hopsFromSeed: 1\r\nvia: "+server.URL+"/start\r\noutlink: "+server.URL+"/about\r\n", metadata.block)
+}
+
+func TestMiddleware_RedirectSuccess(t *testing.T) {
+	defer os.RemoveAll(testWARCDir)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><body>New</body></html>`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	w, err := NewWriter(testWARCDir, "crawl", 0)
+	require.NoError(t, err)
+
+	h := w.Middleware()(fetchHandler{})
+	req := &queue.Request{URL: server.URL + "/old", Header: http.Header{"Accept-Language": {"en"}}}
+	resp, _, err := h.Fetch(context.Background(), req)
+	require.NoError(t, err)
+
+	_, _, err = h.Parse(context.Background(), req, resp)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	records := readRecords(t, w.Files()[0])
+	require.Len(t, records, 6)
+
+	// The redirect is recorded under the requested URL before the page
+	redirect := records[1]
+	require.Equal(t, TypeResponse, redirect.header.Get("WARC-Type"))
+	require.Equal(t, server.URL+"/old", redirect.header.Get("WARC-Target-URI"))
+	require.True(t, strings.HasPrefix(redirect.block, "HTTP/1.1 301 Moved Permanently\r\n"))
+	require.Contains(t, redirect.block, "Location: /new\r\n")
+
+	for i, path := range map[int]string{2: "/old", 4: "/new"} {
+		request := records[i]
+		require.Equal(t, TypeRequest, request.header.Get("WARC-Type"))
+		require.Equal(t, server.URL+path, request.header.Get("WARC-Target-URI"))
+		require.Equal(t, records[i-1].header.Get("WARC-Record-ID"), request.header.Get("WARC-Concurrent-To"))
+		require.True(t, strings.HasPrefix(request.block, "GET "+path+" HTTP/1.1\r\n"))
+
+		// The headers added by the transport are recorded
+		require.Contains(t, request.block, "Accept-Language: en\r\n")
+		require.Contains(t, request.block, "User-Agent: Go-http-client/1.1\r\n")
+		require.Contains(t, request.block, "Accept-Encoding: gzip\r\n")
+	}
+
+	page := records[3]
+	require.Equal(t, TypeResponse, page.header.Get("WARC-Type"))
+	require.Equal(t, server.URL+"/new", page.header.Get("WARC-Target-URI"))
+	require.True(t, strings.HasPrefix(page.block, "HTTP/1.1 200 OK\r\n"))
+
+	metadata := records[5]
+	require.Equal(t, TypeMetadata, metadata.header.Get("WARC-Type"))
+	require.Equal(t, page.header.Get("WARC-Record-ID"), metadata.header.Get("WARC-Refers-To"))
+}
+
+func TestWriteExchange_RotateSuccess(t *testing.T) {
+	defer os.RemoveAll(testWARCDir)
+
+	w, err := NewWriter(testWARCDir, "crawl", 100)
+	require.NoError(t, err)
+
+	for _, path := range []string{"/a", "/b", "/c"} {
+		req, err := http.NewRequest(http.MethodGet, "https://example.com"+path, nil)
+		require.NoError(t, err)
+
+		resp := &http.Response{
+			StatusCode: http.StatusOK,
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{"Content-Type": {"text/plain"}},
+			Request:    req,
+		}
+		require.NoError(t, w.WriteExchange(resp, []byte("page "+path), Metadata{}))
+	}
+	require.NoError(t, w.Close())
+
+	// Every exchange exceeds the size, so it's written to the new file with its own warcinfo
+	files := w.Files()
+	require.Len(t, files, 3)
+	for i, f := range files {
+		require.True(t, strings.HasSuffix(f, "-0000"+strconv.Itoa(i)+".warc.gz"))
+
+		records := readRecords(t, f)
+		require.Len(t, records, 4)
+		require.Equal(t, TypeWarcinfo, records[0].header.Get("WARC-Type"))
+		require.Equal(t, TypeResponse, records[1].header.Get("WARC-Type"))
+	}
+}
+
+// fetchHandler fetches the pages with the fetcher and parses them with the HTML parser.
+type fetchHandler struct{}
+
+func (fetchHandler) Fetch(ctx context.Context, req *queue.Request) (*http.Response, parser.Timing, error) {
+	return fetcher.New().Fetch(ctx, req.URL, req.Header)
+}
+
+func (fetchHandler) Parse(_ context.Context, _ *queue.Request, resp *http.Response) (parser.PageData, []string, error) {
+	p := parser.New()
+
+	return p.ParseResponse(resp)
+}